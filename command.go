@@ -0,0 +1,641 @@
+package golf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// helpCommandName is the name of the built-in help command.
+const helpCommandName = "help"
+
+// Command is a named operation of a command line program, with its own Parser
+// for the options it accepts, and optionally with nested child commands, or
+// sub-commands. A program builds a tree of commands, then invokes Execute on
+// the root command to select and run the command requested by its arguments.
+type Command struct {
+	// Name is the word that selects the command from the arguments of its
+	// parent command. When the Name of the root command is empty, the base
+	// name of the program is displayed in its usage.
+	Name string
+
+	// Aliases are alternate words that also select the command from the
+	// arguments of its parent command.
+	Aliases []string
+
+	// PrefixMatching allows an unambiguous prefix of the name or of an alias
+	// of a child command to select that child. When false, the setting of the
+	// parent command applies.
+	PrefixMatching bool
+
+	// Summary is a brief description of the command, displayed in its usage
+	// and in the list of commands of its parent command.
+	Summary string
+
+	// Hidden omits the command from the list of commands of its parent
+	// command, and from suggestions and prefix matching, but the command may
+	// still be selected by its name or aliases.
+	Hidden bool
+
+	// Deprecated, when not empty, marks the command as deprecated, and is
+	// the message displayed when the command is selected. A deprecated
+	// command is omitted from the list of commands of its parent command,
+	// like a hidden command, but continues to work. Setting the environment
+	// variable GOLF_NO_DEPRECATION_WARNINGS to a non-empty value silences
+	// deprecation messages.
+	Deprecated string
+
+	// ReplacedBy is the optional name of the command that replaces a
+	// deprecated command, displayed along with its deprecation message.
+	ReplacedBy string
+
+	// Parser parses the options accepted by the command.
+	Parser Parser
+
+	// Persistent declares global options accepted by the command and by all
+	// of its descendant commands, anywhere after the name of the command,
+	// including after the names of its descendant commands. Descendant
+	// commands may not declare options with the same flags.
+	Persistent Parser
+
+	// Run is invoked with the remaining arguments after the options of the
+	// selected command have been parsed. When a command has child commands
+	// but no Run or RunContext function, one of its child commands must be
+	// selected.
+	Run func(args []string) error
+
+	// RunContext, when not nil, is invoked rather than Run, with the context
+	// provided to ExecuteContext, which a Runner cancels when the program
+	// receives a signal. Its error is mapped to an exit status code by
+	// ExitCode.
+	RunContext func(ctx context.Context, args []string) error
+
+	// Plugins, when not nil, discovers external executables that act as child
+	// commands. When an argument does not select a child command, the
+	// executable named after the path of the command and the argument,
+	// separated by hyphens, such as "prog-foo", is run with the remaining
+	// arguments, which are passed through verbatim. When nil, the Plugins of
+	// the parent command are used.
+	Plugins PluginFinder
+
+	// Output is where error and usage messages are written. When nil, the
+	// Output of the parent command is used, or standard error for the root
+	// command.
+	Output io.Writer
+
+	parent   *Command
+	children []*Command
+}
+
+// UsageError is returned by Execute when the arguments cannot be parsed, or do
+// not select a command that can be run. Execute writes the error and the
+// usage of Command before returning it.
+type UsageError struct {
+	Command *Command // command whose arguments were invalid
+	Err     error    // reason the arguments were invalid
+}
+
+func (e *UsageError) Error() string { return e.Command.Path() + ": " + e.Err.Error() }
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// UnknownCommandError is wrapped by the *UsageError returned by Execute when an
+// argument does not select a child command.
+type UnknownCommandError struct {
+	Name        string   // name provided in the arguments
+	Suggestions []string // names of child commands with similar names
+}
+
+func (e *UnknownCommandError) Error() string {
+	switch len(e.Suggestions) {
+	case 0:
+		return fmt.Sprintf("unknown command: %q", e.Name)
+	case 1:
+		return fmt.Sprintf("unknown command: %q; did you mean %q?", e.Name, e.Suggestions[0])
+	default:
+		return fmt.Sprintf("unknown command: %q; did you mean one of: %s?", e.Name, quoteJoin(e.Suggestions))
+	}
+}
+
+// AmbiguousCommandError is wrapped by the *UsageError returned by Execute when
+// an argument is a prefix of more than one child command while prefix
+// matching.
+type AmbiguousCommandError struct {
+	Name       string   // name provided in the arguments
+	Candidates []string // names of child commands the name is a prefix of
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	return fmt.Sprintf("ambiguous command: %q could be: %s", e.Name, quoteJoin(e.Candidates))
+}
+
+// AddCommand adds one or more child commands to c, and returns c. It panics
+// when a child command has no name, already has a parent, or has the same
+// name as another child of c.
+func (c *Command) AddCommand(children ...*Command) *Command {
+	for _, child := range children {
+		if child.Name == "" {
+			panic(errors.New("cannot add command without a name"))
+		}
+		if child.parent != nil {
+			panic(fmt.Errorf("cannot add command that already has a parent: %q", child.Name))
+		}
+		for _, name := range append([]string{child.Name}, child.Aliases...) {
+			if c.command(name) != nil {
+				panic(fmt.Errorf("cannot add command that duplicates command: %q", name))
+			}
+		}
+		child.parent = c
+		c.children = append(c.children, child)
+	}
+	return c
+}
+
+// command returns the child command with the specified name or alias, or nil
+// when c has no such child.
+func (c *Command) command(name string) *Command {
+	for _, child := range c.children {
+		if child.Name == name {
+			return child
+		}
+		for _, alias := range child.Aliases {
+			if alias == name {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// Commands returns the child commands of c, in the order they were added.
+func (c *Command) Commands() []*Command {
+	return c.children
+}
+
+// deprecation returns the deprecation message of c, including the name of its
+// replacement when it has one.
+func (c *Command) deprecation() string {
+	if c.ReplacedBy == "" {
+		return c.Deprecated
+	}
+	return fmt.Sprintf("%s; use %q instead", c.Deprecated, c.ReplacedBy)
+}
+
+// dispatches returns true when the arguments of c after its options select a
+// child command, which is when c has child commands, or when c has no Run
+// function but may run plugins.
+func (c *Command) dispatches() bool {
+	return len(c.children) > 0 || (!c.runnable() && c.plugins() != nil)
+}
+
+// editDistance returns the Levenshtein distance between a and b, which is the
+// minimum number of single rune insertions, deletions, and substitutions
+// required to change one into the other.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Execute parses args, which ought not include the name of the program, to
+// select a command from the tree rooted at c, then invokes the RunContext or
+// Run function of the selected command with its remaining arguments. Options
+// of each command must appear after its name and before the name of its child
+// command. When the arguments are invalid, Execute writes the error and the
+// usage of the relevant command to its Output, and returns a *UsageError. When
+// the "-h" or "--help" flag requests help, Execute writes the usage of the
+// relevant command to its Output, and returns ErrHelp. When an argument
+// selects a plugin, Execute returns the error from running the plugin.
+// Otherwise Execute returns the error returned by the Run function.
+//
+// Unless it has a child command named "help", a command with child commands
+// also has a built-in "help" command. The arguments after "help" select a
+// descendant command, whose usage is written to its Output.
+func (c *Command) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext is like Execute, but provides ctx to the RunContext function
+// of the selected command.
+func (c *Command) ExecuteContext(ctx context.Context, args []string) error {
+	// Conflicts are reported even in commands that args do not select.
+	if err := c.inheritTree(); err != nil {
+		return err
+	}
+
+	cmd := c
+	var parsers []*Parser // parsers of the selected command and its ancestors
+
+	for {
+		// A command with children stops parsing its own options at the
+		// first argument, which names the child command.
+		dispatches := cmd.dispatches()
+		cmd.Parser.stopAtArgument = dispatches
+
+		if err := cmd.Parser.Parse(args); err != nil {
+			if err == ErrHelp {
+				cmd.PrintUsageTo(cmd.output())
+				return ErrHelp
+			}
+			return cmd.usageError(err)
+		}
+		args = cmd.Parser.Args()
+		parsers = append(parsers, &cmd.Parser)
+
+		if !dispatches {
+			break
+		}
+		if len(args) == 0 {
+			if cmd.runnable() {
+				break
+			}
+			return cmd.usageError(errors.New("missing command"))
+		}
+
+		if args[0] == helpCommandName && cmd.command(helpCommandName) == nil {
+			return cmd.help(ctx, args[1:])
+		}
+
+		child, err := cmd.lookup(args[0])
+		if err != nil {
+			if _, ok := err.(*UnknownCommandError); ok {
+				if found, err := cmd.runPlugin(ctx, args[0], args[1:]); found {
+					return err
+				}
+			}
+			if cmd.runnable() {
+				break
+			}
+			return cmd.usageError(err)
+		}
+		cmd, args = child, args[1:]
+		cmd.warnDeprecated()
+	}
+
+	// Persistent options may be provided after the names of child commands,
+	// so they are only missing when no parser on the path was provided them.
+	if err := cmd.Parser.ensureRequired(cmd.Parser.inherited, parsers...); err != nil {
+		return cmd.usageError(err)
+	}
+
+	switch {
+	case cmd.RunContext != nil:
+		return cmd.RunContext(ctx, args)
+	case cmd.Run != nil:
+		return cmd.Run(args)
+	default:
+		return cmd.usageError(errors.New("command cannot be run"))
+	}
+}
+
+// globals returns the persistent options declared by the ancestors of c.
+func (c *Command) globals() []option {
+	if c.parent == nil {
+		return nil
+	}
+	return append(c.parent.globals(), c.parent.Persistent.options...)
+}
+
+// help writes the usage of the descendant command of c selected by names to
+// its Output. When the first name is "--all", it writes the usage of the
+// selected command and all of its descendants instead. A plugin selected by
+// the last name is run with ctx to write its own usage.
+func (c *Command) help(ctx context.Context, names []string) error {
+	all := len(names) > 0 && names[0] == "--all"
+	if all {
+		names = names[1:]
+	}
+
+	cmd := c
+	for i, name := range names {
+		child, err := cmd.lookup(name)
+		if err != nil {
+			if _, ok := err.(*UnknownCommandError); ok && i == len(names)-1 {
+				// A plugin provides its own help.
+				if found, err := cmd.runPlugin(ctx, name, []string{"--help"}); found {
+					return err
+				}
+			}
+			return cmd.usageError(err)
+		}
+		cmd = child
+	}
+	if all {
+		cmd.PrintTreeTo(cmd.output())
+	} else {
+		cmd.PrintUsageTo(cmd.output())
+	}
+	return nil
+}
+
+// inherit prepares the Parser of c to also recognize the persistent options of
+// c and of its ancestors, and ensures none of them are redefined by c.
+func (c *Command) inherit() error {
+	if err := c.Persistent.Err(); err != nil {
+		return fmt.Errorf("%s: %w", c.Path(), err)
+	}
+	globals := c.globals()
+
+	for _, opt := range c.Persistent.options {
+		if err := c.Parser.ensureNotGlobal(opt, globals); err != nil {
+			return fmt.Errorf("%s: %w", c.Path(), err)
+		}
+	}
+	for _, opt := range c.Parser.options {
+		if err := c.Parser.ensureNotGlobal(opt, globals); err != nil {
+			return fmt.Errorf("%s: %w", c.Path(), err)
+		}
+		if err := c.Parser.ensureNotGlobal(opt, c.Persistent.options); err != nil {
+			return fmt.Errorf("%s: %w", c.Path(), err)
+		}
+	}
+
+	inherited := make([]option, 0, len(c.Persistent.options)+len(globals))
+	inherited = append(inherited, c.Persistent.options...)
+	c.Parser.inherited = append(inherited, globals...)
+	return nil
+}
+
+// inheritTree calls inherit for c and for each of its descendants.
+func (c *Command) inheritTree() error {
+	if err := c.inherit(); err != nil {
+		return err
+	}
+	for _, child := range c.children {
+		if err := child.inheritTree(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listed returns true when c ought to be displayed in the list of commands of
+// its parent command.
+func (c *Command) listed() bool {
+	return !c.Hidden && c.Deprecated == ""
+}
+
+// lookup returns the child command selected by name, which is either its name
+// or alias, or when prefix matching, an unambiguous prefix of either. When no
+// child is selected, it returns an *UnknownCommandError or an
+// *AmbiguousCommandError.
+func (c *Command) lookup(name string) (*Command, error) {
+	if child := c.command(name); child != nil {
+		return child, nil
+	}
+
+	if c.prefixMatching() {
+		var candidates []*Command
+		for _, child := range c.children {
+			if !child.listed() {
+				continue
+			}
+			for _, word := range append([]string{child.Name}, child.Aliases...) {
+				if strings.HasPrefix(word, name) {
+					candidates = append(candidates, child)
+					break
+				}
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			// no candidates, so suggest similar names
+		case 1:
+			return candidates[0], nil
+		default:
+			names := make([]string, len(candidates))
+			for i, child := range candidates {
+				names[i] = child.Name
+			}
+			return nil, &AmbiguousCommandError{Name: name, Candidates: names}
+		}
+	}
+
+	return nil, &UnknownCommandError{Name: name, Suggestions: c.suggestions(name)}
+}
+
+// output returns the writer for error and usage messages of c.
+func (c *Command) output() io.Writer {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.Output != nil {
+			return cmd.Output
+		}
+	}
+	return os.Stderr
+}
+
+// Parent returns the parent command of c, or nil when c is a root command.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Path returns the names of c and each of its ancestors, starting with the
+// root command, separated by spaces.
+func (c *Command) Path() string {
+	if c.parent == nil {
+		if c.Name == "" {
+			return filepath.Base(os.Args[0])
+		}
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// prefixMatching returns true when an unambiguous prefix may select a child
+// command of c.
+func (c *Command) prefixMatching() bool {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.PrefixMatching {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintTree prints the usage of c and all of its descendants to its Output.
+func (c *Command) PrintTree() {
+	c.PrintTreeTo(c.output())
+}
+
+// printTree prints to w the usage of c and its descendants, as described by
+// PrintTreeTo, with each line of the usage of c prefixed by indent.
+func (c *Command) printTree(w io.Writer, indent string) {
+	iw := &indentWriter{w: w, indent: indent}
+
+	fmt.Fprintf(iw, "%s\n", c.synopsis(true))
+	if c.Summary != "" {
+		fmt.Fprint(iw, c.Parser.wrapper("").Wrap(c.Summary))
+	}
+
+	options := make([]option, 0, len(c.Parser.options)+len(c.Persistent.options))
+	options = append(options, c.Parser.options...)
+	options = append(options, c.Persistent.options...)
+	c.Parser.printOptions(iw, "Options", options)
+
+	for _, child := range c.children {
+		if child.listed() {
+			fmt.Fprintln(w)
+			child.printTree(w, indent+"  ")
+		}
+	}
+}
+
+// PrintTreeTo prints to w the synopsis, summary, and the default settings of
+// the options of c and each of its descendants, indenting each command by its
+// depth below c. Hidden and deprecated commands, and their descendants, are
+// omitted.
+func (c *Command) PrintTreeTo(w io.Writer) {
+	c.printTree(w, "")
+}
+
+// PrintUsage prints the usage of c to its Output.
+func (c *Command) PrintUsage() {
+	c.PrintUsageTo(c.output())
+}
+
+// quoteJoin returns the quoted forms of names separated by commas.
+func quoteJoin(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// runnable returns true when c has a Run or RunContext function.
+func (c *Command) runnable() bool {
+	return c.Run != nil || c.RunContext != nil
+}
+
+// suggestions returns the names of the child commands of c whose name or alias
+// is similar to name, ordered from most to least similar.
+func (c *Command) suggestions(name string) []string {
+	const maxDistance = 2
+
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var similar []suggestion
+
+	for _, child := range c.children {
+		if !child.listed() {
+			continue
+		}
+		best := -1
+		for _, word := range append([]string{child.Name}, child.Aliases...) {
+			d := editDistance(name, word)
+			if strings.HasPrefix(word, name) {
+				d = 0 // a prefix is always a good suggestion
+			}
+			if best == -1 || d < best {
+				best = d
+			}
+		}
+		if best <= maxDistance && best < utf8.RuneCountInString(name) {
+			similar = append(similar, suggestion{name: child.Name, distance: best})
+		}
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool { return similar[i].distance < similar[j].distance })
+
+	var names []string
+	for _, s := range similar {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// synopsis returns the path of c followed by a brief description of the
+// options and arguments it accepts, as described by Parser.Synopsis. Global
+// options are not included.
+func (c *Command) synopsis(interactive bool) string {
+	options := make([]option, 0, len(c.Parser.options)+len(c.Persistent.options))
+	options = append(options, c.Parser.options...)
+	options = append(options, c.Persistent.options...)
+	words := append([]string{c.Path()}, c.Parser.synopsisOptions(options, interactive)...)
+
+	switch {
+	case c.dispatches() && !c.runnable():
+		words = append(words, "command", "[arguments]")
+	case c.dispatches():
+		words = append(words, "[command]", "[arguments]")
+	case len(c.Parser.positionals) > 0:
+		words = append(words, c.Parser.positionals...)
+	default:
+		words = append(words, "[arguments]")
+	}
+	return strings.Join(words, " ")
+}
+
+// usageError writes err and the usage of c to its Output, and returns err
+// wrapped in a *UsageError.
+func (c *Command) usageError(err error) error {
+	ue := &UsageError{Command: c, Err: err}
+	w := c.output()
+	fmt.Fprintf(w, "%s\n", ue)
+	c.PrintUsageTo(w)
+	return ue
+}
+
+// warnDeprecated writes the deprecation message of c to its Output when c is
+// deprecated, unless deprecation messages have been silenced.
+func (c *Command) warnDeprecated() {
+	if c.Deprecated == "" || os.Getenv(noDeprecationWarningsEnv) != "" {
+		return
+	}
+	fmt.Fprintf(c.output(), "warning: command %q is deprecated: %s\n", c.Path(), c.deprecation())
+}
+
+// indentWriter is an io.Writer that prefixes each line written to the
+// underlying io.Writer with indent.
+type indentWriter struct {
+	w       io.Writer
+	indent  string
+	midLine bool // true when the last byte written did not end a line
+}
+
+func (iw *indentWriter) Write(buf []byte) (int, error) {
+	var written int
+	for len(buf) > 0 {
+		if !iw.midLine && iw.indent != "" && buf[0] != '\n' {
+			if _, err := io.WriteString(iw.w, iw.indent); err != nil {
+				return written, err
+			}
+		}
+		line := buf
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line = buf[:i+1]
+		}
+		n, err := iw.w.Write(line)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		iw.midLine = line[len(line)-1] != '\n'
+		buf = buf[len(line):]
+	}
+	return written, nil
+}
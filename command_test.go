@@ -0,0 +1,566 @@
+package golf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestTree returns a command tree for tests, and a pointer to a slice that
+// records the path and arguments of each command that is run. The tree has
+// children as additional child commands of its root, each of which records
+// its name and arguments when it has no Run function of its own.
+func newTestTree(t *testing.T, children ...*Command) (*Command, *[]string) {
+	t.Helper()
+	var ran []string
+
+	root := &Command{Name: "prog", Summary: "An example program."}
+	root.Parser.WithBoolP('v', "verbose", false, "print verbose info")
+
+	foo := &Command{
+		Name:    "foo",
+		Summary: "Do foo things.",
+		Run: func(args []string) error {
+			ran = append(ran, "foo "+strings.Join(args, " "))
+			return nil
+		},
+	}
+	foo.Parser.WithBool("b", false, "some bool")
+
+	bar := &Command{Name: "bar", Summary: "Do bar things."}
+	baz := &Command{
+		Name:    "baz",
+		Summary: "Do baz things.",
+		Run: func(args []string) error {
+			ran = append(ran, "bar baz "+strings.Join(args, " "))
+			return nil
+		},
+	}
+	baz.Parser.WithInt("i", 0, "some int")
+	bar.AddCommand(baz)
+
+	root.AddCommand(foo, bar)
+	for _, child := range children {
+		if child.Run == nil && child.RunContext == nil {
+			child.Run = func(args []string) error {
+				ran = append(ran, child.Name+" "+strings.Join(args, " "))
+				return nil
+			}
+		}
+		root.AddCommand(child)
+	}
+	return root, &ran
+}
+
+func TestCommandExecute(t *testing.T) {
+	t.Run("leaf", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"-v", "foo", "some", "-b", "other"}))
+
+		ensureStringSlicesMatch(t, *ran, []string{"foo some other"})
+		if got, want := output.String(), ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+
+		ensureError(t, root.Execute([]string{"bar", "baz", "-i", "13", "some"}))
+
+		ensureStringSlicesMatch(t, *ran, []string{"bar baz some"})
+	})
+
+	t.Run("options of parent after child name", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+
+		ensureError(t, root.Execute([]string{"foo", "-v"}), "prog foo: unknown flag: 'v'")
+	})
+
+	t.Run("run error", func(t *testing.T) {
+		sentinel := errors.New("sentinel")
+		root := &Command{Name: "prog", Run: func(args []string) error { return sentinel }}
+
+		if got, want := root.Execute(nil), sentinel; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestCommandUsageError(t *testing.T) {
+	t.Run("missing command", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		err := root.Execute([]string{"-v"})
+		ensureError(t, err, "prog: missing command")
+
+		var ue *UsageError
+		if !errors.As(err, &ue) {
+			t.Fatalf("GOT: %T; WANT: %T", err, ue)
+		}
+		if got, want := ue.Command, root; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got.Path(), want.Path())
+		}
+
+		want := `prog: missing command
+Usage: prog [-v] command [arguments]
+
+An example program.
+
+Commands:
+  foo   Do foo things.
+  bar   Do bar things.
+  help  Show help for a command.
+
+Options:
+  -v, --verbose
+    print verbose info
+`
+		if got := output.String(); got != want {
+			t.Errorf("\nGOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"bar", "qux"}), `prog bar: unknown command: "qux"`)
+
+		want := `prog bar: unknown command: "qux"
+Usage: prog bar command [arguments]
+
+Do bar things.
+
+Commands:
+  baz   Do baz things.
+  help  Show help for a command.
+`
+		if got := output.String(); got != want {
+			t.Errorf("\nGOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("invalid option of leaf", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"bar", "baz", "-i", "x"}), "prog bar baz: strconv.Atoi")
+
+		if got, want := output.String(), "Usage: prog bar baz [-i INT] [arguments]\n"; !strings.Contains(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestCommandAddCommand(t *testing.T) {
+	t.Run("duplicate", func(t *testing.T) {
+		root := &Command{Name: "prog"}
+		root.AddCommand(&Command{Name: "foo"})
+		ensurePanic(t, `cannot add command that duplicates command: "foo"`, func() {
+			root.AddCommand(&Command{Name: "foo"})
+		})
+	})
+
+	t.Run("without name", func(t *testing.T) {
+		root := &Command{Name: "prog"}
+		ensurePanic(t, "cannot add command without a name", func() {
+			root.AddCommand(&Command{})
+		})
+	})
+
+	t.Run("path", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		if got, want := root.Commands()[1].Commands()[0].Path(), "prog bar baz"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestCommandPersistent(t *testing.T) {
+	t.Run("before and after command names", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		quiet := root.Persistent.WithBoolP('q', "quiet", false, "print nothing")
+		config := root.Persistent.WithString("config", "", "read configuration file")
+		root.Commands()[1].Parser.WithBool("b", false, "some bool")
+
+		ensureError(t, root.Execute([]string{"--config", "a.conf", "bar", "-b", "baz", "some", "-q", "-i", "3"}))
+
+		if got, want := *quiet, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := *config, "a.conf"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureStringSlicesMatch(t, *ran, []string{"bar baz some"})
+	})
+
+	t.Run("help sections", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Persistent.WithBoolP('q', "quiet", false, "print nothing")
+		root.Persistent.WithString("config", "", "read configuration file")
+
+		var output strings.Builder
+		root.Commands()[1].Commands()[0].PrintUsageTo(&output)
+
+		want := `Usage: prog bar baz [-i INT] [arguments]
+
+Do baz things.
+
+Options:
+  -i INT (default: 0)
+    some int
+
+Global options:
+  -q, --quiet
+    print nothing
+  --config STRING (default: "")
+    read configuration file
+`
+		if got := output.String(); got != want {
+			t.Errorf("\nGOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("child redefinition", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Persistent.WithBoolP('q', "quiet", false, "print nothing")
+		root.Commands()[1].Commands()[0].Parser.WithBool("quiet", false, "conflicts with global")
+
+		ensureError(t, root.Execute([]string{"bar", "baz"}), `prog bar baz: cannot add option that duplicates global long flag: "quiet"`)
+		ensureStringSlicesMatch(t, *ran, nil)
+	})
+
+	t.Run("persistent redefinition", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Persistent.WithBoolP('q', "quiet", false, "print nothing")
+		root.Commands()[1].Persistent.WithBool("q", false, "conflicts with global")
+
+		ensureError(t, root.Execute([]string{"bar", "baz"}), `prog bar: cannot add option that duplicates global short flag: "q"`)
+	})
+
+	t.Run("required", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"--config", "prog.conf", "bar", "baz"},
+			{"bar", "--config", "prog.conf", "baz"},
+			{"bar", "baz", "--config", "prog.conf"},
+		} {
+			root, ran := newTestTree(t)
+			config := root.Persistent.WithString("config", "", "read configuration file")
+			root.Persistent.WithRequired("config")
+			ensureError(t, root.Execute(args))
+			if got, want := *config, "prog.conf"; got != want {
+				t.Errorf("%q: GOT: %q; WANT: %q", args, got, want)
+			}
+			ensureStringSlicesMatch(t, *ran, []string{"bar baz "})
+		}
+
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Persistent.WithString("config", "", "read configuration file")
+		root.Persistent.WithRequired("config")
+		ensureError(t, root.Execute([]string{"bar", "baz"}), `prog bar baz: missing required flag: "--config"`)
+		ensureStringSlicesMatch(t, *ran, nil)
+	})
+
+	t.Run("normalized redefinition", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Persistent.WithBool("dry-run", false, "do not change anything")
+		bar := root.Commands()[1]
+		bar.Parser.WithNormalizer(NormalizeSeparators)
+		bar.Parser.WithBool("dry_run", false, "conflicts with global")
+
+		ensureError(t, root.Execute([]string{"bar", "baz"}), `prog bar: cannot add option that duplicates global long flag: "dry_run"`)
+	})
+
+	t.Run("redefinition in command not selected", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Persistent.WithString("config", "", "read configuration file")
+		root.Commands()[1].Commands()[0].Parser.WithBool("config", false, "conflicts with global")
+
+		ensureError(t, root.Execute([]string{"foo"}), `prog bar baz: cannot add option that duplicates global long flag: "config"`)
+	})
+}
+
+func TestCommandLookup(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		prefix     bool
+		args       []string
+		ran        []string
+		err        string
+		candidates []string // suggestions, or candidates of an ambiguous prefix
+	}{
+		{name: "alias", args: []string{"ls"}, ran: []string{"list "}},
+		{name: "prefix not matched by default", args: []string{"li"}, err: `unknown command: "li"; did you mean "list"?`, candidates: []string{"list"}},
+		{name: "unique prefix", prefix: true, args: []string{"li"}, ran: []string{"list "}},
+		{name: "ambiguous prefix", prefix: true, args: []string{"sta"}, err: `ambiguous command: "sta" could be: "status", "stage", "stash"`, candidates: []string{"status", "stage", "stash"}},
+		{name: "suggestions", args: []string{"stat"}, err: `unknown command: "stat"; did you mean one of: "status", "stage", "stash"?`, candidates: []string{"status", "stage", "stash"}},
+		{name: "transposition", args: []string{"stauts"}, err: `unknown command: "stauts"; did you mean "status"?`, candidates: []string{"status"}},
+		{name: "no suggestions", args: []string{"frobnicate"}, err: `unknown command: "frobnicate"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root, ran := newTestTree(t,
+				&Command{Name: "list", Aliases: []string{"ls"}},
+				&Command{Name: "status"},
+				&Command{Name: "stage"},
+				&Command{Name: "stash"},
+			)
+			root.Output = new(strings.Builder)
+			root.PrefixMatching = tc.prefix
+
+			err := root.Execute(tc.args)
+			ensureError(t, err, tc.err)
+			ensureStringSlicesMatch(t, *ran, tc.ran)
+
+			var uce *UnknownCommandError
+			var ace *AmbiguousCommandError
+			switch {
+			case errors.As(err, &uce):
+				ensureStringSlicesMatch(t, uce.Suggestions, tc.candidates)
+			case errors.As(err, &ace):
+				ensureStringSlicesMatch(t, ace.Candidates, tc.candidates)
+			}
+		})
+	}
+
+	t.Run("alias duplicates command", func(t *testing.T) {
+		root, _ := newTestTree(t, &Command{Name: "list", Aliases: []string{"ls"}})
+		ensurePanic(t, `cannot add command that duplicates command: "ls"`, func() {
+			root.AddCommand(&Command{Name: "dir", Aliases: []string{"ls"}})
+		})
+	})
+}
+
+func TestCommandHiddenAndDeprecated(t *testing.T) {
+	t.Run("omitted from usage", func(t *testing.T) {
+		root, _ := newTestTree(t,
+			&Command{Name: "secret", Summary: "Do secret things.", Hidden: true},
+			&Command{Name: "old", Summary: "Do old things.", Deprecated: "no longer maintained"},
+		)
+		var output strings.Builder
+		root.PrintUsageTo(&output)
+		if got := output.String(); strings.Contains(got, "secret") || strings.Contains(got, "old") {
+			t.Errorf("GOT: %q; WANT: no hidden or deprecated commands", got)
+		}
+	})
+
+	t.Run("hidden runs", func(t *testing.T) {
+		root, ran := newTestTree(t, &Command{Name: "secret", Hidden: true})
+		var output strings.Builder
+		root.Output = &output
+		ensureError(t, root.Execute([]string{"secret"}))
+		ensureStringSlicesMatch(t, *ran, []string{"secret "})
+		if got, want := output.String(), ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("hidden not suggested", func(t *testing.T) {
+		root, _ := newTestTree(t, &Command{Name: "secret", Hidden: true})
+		root.Output = new(strings.Builder)
+		err := root.Execute([]string{"secre"})
+		ensureError(t, err, `unknown command: "secre"`)
+		if strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("GOT: %q; WANT: no suggestions", err)
+		}
+	})
+
+	t.Run("hidden not prefix matched", func(t *testing.T) {
+		root, _ := newTestTree(t, &Command{Name: "secret", Hidden: true})
+		root.Output = new(strings.Builder)
+		root.PrefixMatching = true
+		ensureError(t, root.Execute([]string{"sec"}), `unknown command: "sec"`)
+	})
+
+	t.Run("deprecated warns", func(t *testing.T) {
+		root, ran := newTestTree(t, &Command{Name: "old", Deprecated: "no longer maintained", ReplacedBy: "foo"})
+		var output strings.Builder
+		root.Output = &output
+		ensureError(t, root.Execute([]string{"old"}))
+		ensureStringSlicesMatch(t, *ran, []string{"old "})
+		if got, want := output.String(), "warning: command \"prog old\" is deprecated: no longer maintained; use \"foo\" instead\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("deprecated silenced", func(t *testing.T) {
+		t.Setenv(noDeprecationWarningsEnv, "1")
+		root, ran := newTestTree(t, &Command{Name: "old", Deprecated: "no longer maintained", ReplacedBy: "foo"})
+		var output strings.Builder
+		root.Output = &output
+		ensureError(t, root.Execute([]string{"old"}))
+		ensureStringSlicesMatch(t, *ran, []string{"old "})
+		if got, want := output.String(), ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("deprecated usage", func(t *testing.T) {
+		root, _ := newTestTree(t, &Command{Name: "old", Deprecated: "no longer maintained", ReplacedBy: "foo"})
+		var output strings.Builder
+		root.Commands()[2].PrintUsageTo(&output)
+		if got, want := output.String(), "Deprecated: no longer maintained; use \"foo\" instead"; !strings.Contains(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestCommandPrintTree(t *testing.T) {
+	want := `prog [-v] command [arguments]
+An example program.
+Options:
+  -v, --verbose
+    print verbose info
+
+  prog foo [-b] [arguments]
+  Do foo things.
+  Options:
+    -b
+      some bool
+
+  prog bar command [arguments]
+  Do bar things.
+
+    prog bar baz [-i INT] [arguments]
+    Do baz things.
+    Options:
+      -i INT (default: 0)
+        some int
+`
+
+	t.Run("print tree", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.AddCommand(&Command{Name: "secret", Hidden: true, Summary: "Do secret things."})
+
+		var output strings.Builder
+		root.PrintTreeTo(&output)
+		if got := output.String(); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("help all", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"help", "--all"}))
+		if got := output.String(); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("help all for command", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"help", "--all", "bar"}))
+		if got, want := output.String(), "prog bar command [arguments]\nDo bar things.\n\n  prog bar baz"; !strings.HasPrefix(got, want) {
+			t.Errorf("GOT: %q; WANT PREFIX: %q", got, want)
+		}
+	})
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"status", "status", 0},
+		{"stat", "status", 2},
+		{"stauts", "status", 2},
+		{"ls", "list", 2},
+		{"kitten", "sitting", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("%q %q: GOT: %v; WANT: %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	t.Run("flags", func(t *testing.T) {
+		for _, args := range [][]string{{"-h"}, {"--help"}, {"bar", "-h"}, {"bar", "baz", "--help"}, {"bar", "baz", "-i", "3", "-h"}} {
+			root, ran := newTestTree(t)
+			var output strings.Builder
+			root.Output = &output
+
+			if got, want := root.Execute(args), ErrHelp; got != want {
+				t.Errorf("%q: GOT: %v; WANT: %v", args, got, want)
+			}
+			if got, want := output.String(), "Usage: prog "; !strings.HasPrefix(got, want) {
+				t.Errorf("%q: GOT: %q; WANT: %q", args, got, want)
+			}
+			ensureStringSlicesMatch(t, *ran, nil)
+		}
+	})
+
+	t.Run("command", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"help", "bar", "baz"}))
+
+		want := `Usage: prog bar baz [-i INT] [arguments]
+
+Do baz things.
+
+Options:
+  -i INT (default: 0)
+    some int
+`
+		if got := output.String(); got != want {
+			t.Errorf("\nGOT:\n%s\nWANT:\n%s", got, want)
+		}
+		ensureStringSlicesMatch(t, *ran, nil)
+	})
+
+	t.Run("command without arguments", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		var output strings.Builder
+		root.Output = &output
+
+		ensureError(t, root.Execute([]string{"help"}))
+
+		if got, want := output.String(), "Usage: prog [-v] command [arguments]\n"; !strings.HasPrefix(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("command with unknown command", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+
+		ensureError(t, root.Execute([]string{"help", "bar", "qux"}), `prog bar: unknown command: "qux"`)
+	})
+
+	t.Run("program declares help", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+		help := root.Parser.WithBoolP('h', "help", false, "show help")
+
+		ensureError(t, root.Execute([]string{"-h", "foo"}))
+		if got, want := *help, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/karrick/golf"
+)
+
+func main() {
+	// Declare the root command, which represents the program itself.
+	root := &golf.Command{Summary: "Example program with sub-commands."}
+
+	// Declare and configure a command, along with its parser, to handle the
+	// command line options for the 'foo' sub-command.
+	foo := &golf.Command{Name: "foo", Summary: "Print some bool and duration."}
+	optBool := foo.Parser.WithBool("b", false, "some bool")
+	optDuration := foo.Parser.WithDurationP('d', "duration", 0, "some duration")
+	foo.Run = func(args []string) error {
+		// Do the sub-command operation with the arguments.
+		fmt.Println("optBool:", *optBool)
+		fmt.Println("optDuration:", *optDuration)
+
+		// The arguments not consumed by the Parser variables are provided to
+		// the Run function.
+		for i, arg := range args {
+			fmt.Fprintf(os.Stderr, "# %d: %s\n", i, arg)
+		}
+		return nil
+	}
+
+	// Declare and configure a command, along with its parser, to handle the
+	// command line options for the 'bar' sub-command.
+	bar := &golf.Command{Name: "bar", Summary: "Print some int and string."}
+	optInt := bar.Parser.WithInt("i", 0, "some int")
+	optString := bar.Parser.WithString("s", "", "some string")
+	bar.Run = func(args []string) error {
+		fmt.Println("optInt:", *optInt)
+		fmt.Println("optString:", *optString)
+		for i, arg := range args {
+			fmt.Fprintf(os.Stderr, "# %d: %s\n", i, arg)
+		}
+		return nil
+	}
+
+	root.AddCommand(foo, bar)
+
+	// Run selects the sub-command, parses its options, and runs it, then
+	// exits the program with an appropriate status code. When the arguments
+	// are invalid, it prints the error and the relevant usage, and exits with
+	// status code 2. When help is requested with "-h" or "--help", it prints
+	// the relevant usage and exits with status code 0.
+	golf.Run(root)
+}
@@ -13,8 +13,11 @@ func main() {
 	// As a reminder, the first argument provided is the name of the
 	// program. Therefore if there is only a single command line argument,
 	// then the caller did not provide a sub-command.
+	basename := filepath.Base(os.Args[0])
+	usage := basename + " foo|bar [options] [arguments]"
+
 	if len(os.Args) == 1 {
-		bail(errors.New("missing sub-command"))
+		bail(errors.New("missing sub-command"), usage)
 	}
 
 	// os.Args[0]:  The string used to invoke the program.
@@ -27,7 +30,7 @@ func main() {
 	case "bar":
 		bar(subCommandArgs)
 	default:
-		bail(fmt.Errorf("sub-command not recognized: %q", subCommand))
+		bail(fmt.Errorf("sub-command not recognized: %q", subCommand), usage)
 	}
 }
 
@@ -39,10 +42,11 @@ func foo(args []string) {
 	optDuration := p.WithDurationP('d', "duration", 0, "some duration")
 
 	// After the parser has been configured, use it to parse the command line
-	// arguments provided to this function.
+	// arguments provided to this function. The synopsis of the command line,
+	// "example foo [-b] [-d DURATION]", is generated from the parser.
 	err := p.Parse(args)
 	if err != nil {
-		bail(err)
+		bail(err, p.Synopsis(filepath.Base(os.Args[0])+" foo"))
 	}
 
 	// Do the sub-command operation with the arguments.
@@ -67,7 +71,7 @@ func bar(args []string) {
 	// arguments provided to this function.
 	err := p.Parse(args)
 	if err != nil {
-		bail(err)
+		bail(err, p.Synopsis(filepath.Base(os.Args[0])+" bar"))
 	}
 
 	// Do the sub-command operation with the arguments.
@@ -81,10 +85,8 @@ func bar(args []string) {
 	}
 }
 
-func bail(err error) {
-	basename := filepath.Base(os.Args[0])
-	fmt.Fprintf(os.Stderr, "%s: %s\n", basename, err)
-	fmt.Fprintf(os.Stderr, "USAGE %s foo [-b] [-d DURATION]\n", basename)
-	fmt.Fprintf(os.Stderr, "USAGE %s bar [-i INT] [-s STRING]\n", basename)
+func bail(err error, usage string) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(os.Args[0]), err)
+	fmt.Fprintf(os.Stderr, "USAGE %s\n", usage)
 	os.Exit(2)
 }
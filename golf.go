@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -13,9 +14,6 @@ func init() {
 	defaultParser = new(Parser)
 }
 
-// dw is default wrapper, used for printing default options.
-var dw = LineWrapper{Max: 80, Prefix: "    "}
-
 // Arg returns the i'th command-line argument. Arg(0) is the first remaining
 // argument after flags have been processed. Arg returns an empty string if
 // the requested element does not exist.
@@ -39,15 +37,21 @@ func NFlag() int {
 	return defaultParser.NFlag()
 }
 
-// Parse parses the command line. On error, displays the usage of the command
-// line and exits the program with status code 2.
+// Parse parses the command line. When help is requested, displays the usage of
+// the command line and exits the program with status code 0. On error,
+// displays the usage of the command line and exits the program with status
+// code 2.
 func Parse() {
 	if err := defaultParser.Parse(os.Args[1:]); err != nil {
 		// NOTE: Format output then exit similar to how Go standard library
 		// "flag" might.
+		if err == ErrHelp {
+			Usage()
+			os.Exit(exitSuccess)
+		}
 		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
 		Usage()
-		os.Exit(2)
+		os.Exit(exitUsage)
 	}
 }
 
@@ -70,8 +74,56 @@ func PrintDefaultsTo(w io.Writer) {
 	defaultParser.PrintDefaultsTo(w)
 }
 
+// PrintUsage prints to standard error the usage message of the command line
+// parser, starting with the synopsis of the command line generated from its
+// options. Programs may use it to include the synopsis in their usage by
+// setting Usage to PrintUsage.
+func PrintUsage() {
+	defaultParser.PrintUsageTo(os.Stderr, filepath.Base(os.Args[0]))
+}
+
+// Positionals configures the command line parser to display names after the
+// options in the synopsis, describing the positional arguments the program
+// accepts.
+func Positionals(names ...string) {
+	defaultParser.WithPositionals(names...)
+}
+
+// Required configures the command line parser to require the options selected
+// by flags to be provided.
+func Required(flags ...string) {
+	defaultParser.WithRequired(flags...)
+}
+
+// Run executes the command tree rooted at c with the command line arguments,
+// using a context that is cancelled when the program receives an interrupt or
+// terminate signal, then exits the program with the exit status code from
+// ExitCode. Programs that need other signals or a grace period may use a
+// Runner instead.
+func Run(c *Command) {
+	var r Runner
+	os.Exit(r.Run(c, os.Args[1:]))
+}
+
+// RunMultiCall is like Run, but runs c as a multi-call program, selecting a
+// child command by the name under which the program was invoked, as described
+// by ExecuteMultiCallContext.
+func RunMultiCall(c *Command) {
+	var r Runner
+	os.Exit(r.RunMultiCall(c, os.Args))
+}
+
+// SingleHyphenLong configures the command line parser to treat a single hyphen
+// followed by the entire name of a long flag according to mode. Programs
+// migrating from the "flag" standard library package may use this to continue
+// accepting arguments such as "-limit 4".
+func SingleHyphenLong(mode SingleHyphenMode) {
+	defaultParser.WithSingleHyphenLong(mode)
+}
+
 // Usage prints command line usage to stderr, but may be overridden by programs
-// that need to customize the usage information.
+// that need to customize the usage information, such as by setting it to
+// PrintUsage.
 var Usage = func() {
 	// NOTE: Format output similar to how Go standard library "flag" might.
 	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -0,0 +1,20 @@
+package golf
+
+import (
+	"os"
+	"testing"
+)
+
+// environmentWidthProvider is the default WidthProvider of the package, saved
+// before TestMain replaces it, for the tests of the default itself.
+var environmentWidthProvider = defaultWidthProvider
+
+// TestMain runs every test of the package with a default WidthProvider that
+// does not know the width of the terminal, so that help is wrapped to 80
+// columns. Many tests compare help with golden output, which would otherwise
+// depend on the COLUMNS environment variable, or on the terminal that runs
+// them.
+func TestMain(m *testing.M) {
+	defaultWidthProvider = WidthFunc(func() int { return 0 })
+	os.Exit(m.Run())
+}
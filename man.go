@@ -0,0 +1,320 @@
+package golf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManPage generates roff man(7) pages from a Parser or a Command tree, with
+// NAME, SYNOPSIS, DESCRIPTION, OPTIONS, ENVIRONMENT, FILES, and EXAMPLES
+// sections, each of which is omitted when it would be empty. The generated
+// pages do not depend on the time they are generated, unless Date is set,
+// which makes them suitable for golden tests.
+//
+//	m := golf.ManPage{Source: "example 1.2.3", Manual: "User Commands"}
+//	err := m.WriteTree("man/man1", root)
+type ManPage struct {
+	// Section is the manual section of the pages. When empty, pages are in
+	// section "1".
+	Section string
+
+	// Date is the optional date displayed in the footer of the pages.
+	Date string
+
+	// Source is the optional source of the program, such as its name and
+	// version, displayed in the footer of the pages.
+	Source string
+
+	// Manual is the optional title of the manual, such as "User Commands",
+	// displayed in the header of the pages.
+	Manual string
+}
+
+// WriteCommand writes to w the man page of c. The NAME section describes c
+// by its Summary, or else by the first paragraph of the description of its
+// Parser, so it returns an error when c has neither.
+func (m ManPage) WriteCommand(w io.Writer, c *Command) error {
+	page, err := m.render(c.UsageData(), m.seeAlso(c))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, page)
+	return err
+}
+
+// WriteParser writes to w the man page of the Parser for program. The NAME
+// section describes the program by the first paragraph of the description of
+// p, so it returns an error when p has no description.
+func (m ManPage) WriteParser(w io.Writer, p *Parser, program string) error {
+	page, err := m.render(p.UsageData(program), nil)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, page)
+	return err
+}
+
+// WriteTree writes to directory dir the man page of c, and of each of its
+// descendant commands that is neither hidden nor deprecated, one page per
+// command. Each file is named after the path of its command, with spaces
+// replaced by hyphens, and has the section as its extension, such as
+// "example-foo.1".
+func (m ManPage) WriteTree(dir string, c *Command) error {
+	var buf strings.Builder
+	if err := m.WriteCommand(&buf, c); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, m.filename(c)), []byte(buf.String()), 0o644); err != nil {
+		return err
+	}
+	for _, child := range c.children {
+		if child.listed() {
+			if err := m.WriteTree(dir, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filename returns the name of the file of the man page of c.
+func (m ManPage) filename(c *Command) string {
+	return manName(c.Path()) + "." + m.section()
+}
+
+// render returns the man page described by data, referring to the pages
+// named by seeAlso, or an error when data has neither a summary nor a
+// description to describe the program in the NAME section.
+func (m ManPage) render(data UsageData, seeAlso []string) (string, error) {
+	var b strings.Builder
+	name := manName(data.Program)
+
+	summary := data.Summary
+	if summary == "" {
+		// The first paragraph of the description, on a single line.
+		summary, _, _ = strings.Cut(strings.TrimSpace(data.Description), "\n\n")
+		summary = strings.Join(strings.Fields(summary), " ")
+	}
+	if summary == "" {
+		return "", fmt.Errorf("cannot write man page without summary or description: %q", data.Program)
+	}
+
+	b.WriteString(".TH " + manArgs(strings.ToUpper(name), m.section(), m.Date, m.Source, m.Manual) + "\n")
+
+	b.WriteString(".SH NAME\n")
+	b.WriteString(manLines(name + " - " + summary))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	b.WriteString(manBold(data.Program))
+	if rest := strings.TrimSpace(strings.TrimPrefix(data.Synopsis, data.Program)); rest != "" {
+		b.WriteString(" " + manLines(rest))
+	} else {
+		b.WriteString("\n")
+	}
+
+	var description []string
+	if data.Summary != "" {
+		description = append(description, data.Summary)
+	}
+	if data.Description != "" {
+		description = append(description, data.Description)
+	}
+	if len(data.Aliases) > 0 {
+		description = append(description, "Aliases: "+strings.Join(data.Aliases, ", "))
+	}
+	if data.Deprecated != "" {
+		description = append(description, "Deprecated: "+data.Deprecated)
+	}
+	if len(description) > 0 {
+		b.WriteString(".SH DESCRIPTION\n")
+		b.WriteString(manParagraphs(strings.Join(description, "\n\n")))
+	}
+
+	if len(data.Commands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, cmd := range data.Commands {
+			b.WriteString(".TP\n" + manBold(cmd.Name) + "\n")
+			b.WriteString(manParagraphs(cmd.Summary))
+		}
+	}
+
+	var envs []UsageOption
+	if len(data.Groups) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		for _, group := range data.Groups {
+			if len(data.Groups) > 1 {
+				b.WriteString(".SS " + manArgs(group.Name) + "\n")
+			}
+			for _, opt := range group.Options {
+				b.WriteString(".TP\n" + manFlags(opt))
+				if opt.Metavar != "" {
+					b.WriteString(" " + manItalic(opt.Metavar))
+				}
+				b.WriteString("\n")
+				text := opt.Description
+				if opt.Required {
+					text += " (required)"
+				} else if opt.value != "" {
+					text += " (default: " + opt.value + ")"
+				}
+				b.WriteString(manParagraphs(text))
+				if opt.Env != "" {
+					envs = append(envs, opt)
+				}
+			}
+		}
+	}
+
+	if len(envs) > 0 {
+		b.WriteString(".SH ENVIRONMENT\n")
+		for _, opt := range envs {
+			b.WriteString(".TP\n" + manBold(opt.Env) + "\n")
+			b.WriteString("Corresponds to " + manFlags(opt) + ".\n")
+		}
+	}
+
+	if len(data.Files) > 0 {
+		b.WriteString(".SH FILES\n")
+		for _, file := range data.Files {
+			b.WriteString(".TP\n" + manItalic(file.Path) + "\n")
+			b.WriteString(manParagraphs(file.Description))
+		}
+	}
+
+	if len(data.Examples) > 0 {
+		b.WriteString(".SH EXAMPLES\n")
+		for _, example := range data.Examples {
+			b.WriteString(".TP\n" + manBold(example.Command) + "\n")
+			b.WriteString(manParagraphs(example.Description))
+		}
+	}
+
+	if len(seeAlso) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, page := range seeAlso {
+			b.WriteString(manBold(page) + "(" + m.section() + ")")
+			if i < len(seeAlso)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+// section returns the manual section of the pages.
+func (m ManPage) section() string {
+	if m.Section == "" {
+		return "1"
+	}
+	return m.Section
+}
+
+// seeAlso returns the names of the man pages related to the page of c: the
+// page of its parent, followed by the pages of its listed children.
+func (m ManPage) seeAlso(c *Command) []string {
+	var pages []string
+	if c.parent != nil {
+		pages = append(pages, manName(c.parent.Path()))
+	}
+	for _, child := range c.children {
+		if child.listed() {
+			pages = append(pages, manName(child.Path()))
+		}
+	}
+	return pages
+}
+
+// manEscaper escapes the characters that roff would otherwise interpret
+// anywhere in a line. Hyphens are escaped as minus signs so that flags may be
+// copied and searched for.
+var manEscaper = strings.NewReplacer(`\`, `\e`, `-`, `\-`)
+
+// manEscape returns text with the characters that roff would otherwise
+// interpret escaped, including a period or an apostrophe at the start of a
+// line, which would make the line a request.
+func manEscape(text string) string {
+	return manProtect(manEscaper.Replace(text))
+}
+
+// manArgs returns args escaped and quoted as the arguments of a roff request.
+func manArgs(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = `"` + strings.ReplaceAll(manEscape(arg), `"`, `\(dq`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// manBold returns text escaped and set in bold.
+func manBold(text string) string {
+	return `\fB` + manEscape(text) + `\fR`
+}
+
+// manFlags returns the flags of opt, escaped and set in bold.
+func manFlags(opt UsageOption) string {
+	flags := strings.Split(opt.Flags, ", ")
+	for i, flag := range flags {
+		flags[i] = manBold(flag)
+	}
+	return strings.Join(flags, ", ")
+}
+
+// manItalic returns text escaped and set in italics.
+func manItalic(text string) string {
+	return `\fI` + manEscape(text) + `\fR`
+}
+
+// manLines returns text escaped, with its whitespace normalized, and wrapped
+// to 80 columns, ending with a newline. Lines that would otherwise be
+// interpreted as roff requests are protected by a zero-width character.
+func manLines(text string) string {
+	return manProtect(defaultWrapper.Wrap(manEscaper.Replace(text)))
+}
+
+// manName returns the name of the man page of the program or command at path.
+func manName(path string) string {
+	return strings.Join(strings.Fields(path), "-")
+}
+
+// manProtect returns text with each line that starts with a period or an
+// apostrophe, which roff would interpret as a request, preceded by the
+// zero-width character "\&".
+func manProtect(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// manParagraphs returns text escaped and wrapped by manLines, with each of its
+// paragraphs, which are separated by blank lines, separated by a .PP request.
+func manParagraphs(text string) string {
+	var paragraphs []string
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			paragraphs = append(paragraphs, manLines(strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+
+	return strings.Join(paragraphs, ".PP\n")
+}
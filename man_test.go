@@ -0,0 +1,114 @@
+package golf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManPageWriteParser(t *testing.T) {
+	var p Parser
+	p.WithDescription("Serve files over the network.\n\n.Files are served read-only.")
+	p.WithBoolP('v', "verbose", false, "print verbose info")
+	p.WithStringP('s', "server", "localhost", "listen on `HOST`")
+	p.WithInt("port", 80, "listen on port")
+	p.WithString("root", `C:\srv`, "serve files from root")
+	p.WithString("gzip", ".gz", "'.gz' files are served compressed")
+	p.WithGroup("Networking", "server", "port")
+	p.WithEnv("server", "SERVER")
+	p.WithRequired("port")
+	p.WithPositionals("DIR")
+	p.WithFile("/etc/serve.conf", "Default configuration.")
+	p.WithExample("serve -s example.com /srv", "Serve /srv on example.com.")
+	ensureError(t, p.Err())
+
+	var output strings.Builder
+	ensureError(t, ManPage{Source: "serve 1.0", Manual: "User Commands"}.WriteParser(&output, &p, "serve"))
+
+	want := `.TH "SERVE" "1" "" "serve 1.0" "User Commands"
+.SH NAME
+serve \- Serve files over the network.
+.SH SYNOPSIS
+\fBserve\fR [\-v] [\-s HOST] \-\-port INT [\-\-root STRING] [\-\-gzip STRING] DIR
+.SH DESCRIPTION
+Serve files over the network.
+.PP
+\&.Files are served read\-only.
+.SH OPTIONS
+.SS "Options"
+.TP
+\fB\-v\fR, \fB\-\-verbose\fR
+print verbose info
+.TP
+\fB\-\-root\fR \fISTRING\fR
+serve files from root (default: C:\esrv)
+.TP
+\fB\-\-gzip\fR \fISTRING\fR
+\&'.gz' files are served compressed (default: .gz)
+.SS "Networking"
+.TP
+\fB\-s\fR, \fB\-\-server\fR \fIHOST\fR
+listen on HOST (default: localhost)
+.TP
+\fB\-\-port\fR \fIINT\fR
+listen on port (required)
+.SH ENVIRONMENT
+.TP
+\fBSERVER\fR
+Corresponds to \fB\-s\fR, \fB\-\-server\fR.
+.SH FILES
+.TP
+\fI/etc/serve.conf\fR
+Default configuration.
+.SH EXAMPLES
+.TP
+\fBserve \-s example.com /srv\fR
+Serve /srv on example.com.
+`
+	if got := output.String(); got != want {
+		t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+	}
+}
+
+func TestManPageWriteParserWithoutDescription(t *testing.T) {
+	var p Parser
+	p.WithBoolP('v', "verbose", false, "print verbose info")
+	ensureError(t, p.Err())
+
+	var output strings.Builder
+	err := ManPage{}.WriteParser(&output, &p, "serve")
+	ensureError(t, err, `cannot write man page without summary or description: "serve"`)
+	if got := output.String(); got != "" {
+		t.Errorf("GOT: %q; WANT: %q", got, "")
+	}
+}
+
+func TestManPageWriteTree(t *testing.T) {
+	root, _ := newTestTree(t)
+	root.Commands()[0].Hidden = true
+
+	dir := t.TempDir()
+	ensureError(t, ManPage{Section: "8"}.WriteTree(dir, root))
+
+	entries, err := os.ReadDir(dir)
+	ensureError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	ensureStringSlicesMatch(t, names, []string{"prog.8", "prog-bar.8", "prog-bar-baz.8"})
+
+	buf, err := os.ReadFile(filepath.Join(dir, "prog-bar.8"))
+	ensureError(t, err)
+	got := string(buf)
+	for _, want := range []string{
+		".TH \"PROG\\-BAR\" \"8\" \"\" \"\" \"\"\n",
+		".SH COMMANDS\n.TP\n\\fBbaz\\fR\n",
+		".SH SEE ALSO\n\\fBprog\\fR(8),\n\\fBprog\\-bar\\-baz\\fR(8)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("GOT:\n%s\nWANT: %q", got, want)
+		}
+	}
+}
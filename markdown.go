@@ -0,0 +1,218 @@
+package golf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Markdown generates Markdown reference documentation from a Parser or a
+// Command tree. Each command is described by a section with an anchor named
+// after its path, such as "example-foo", that includes its usage, its child
+// commands, tables of its options, the files it uses, and its examples. The
+// sections of a tree link to the sections of their parent and children. The
+// output only depends on the declarations of the program, which makes it
+// suitable for diffing.
+//
+//	golf.Markdown{Level: 2}.WriteTree(os.Stdout, root)
+type Markdown struct {
+	// Level is the level of the heading of each command, from 1 to 5, and
+	// its sections have headings of the following level. When 0, commands
+	// have level 1 headings.
+	Level int
+}
+
+// WriteParser writes to w the reference of the Parser for program. It returns
+// an error when the Level of m is out of range.
+func (m Markdown) WriteParser(w io.Writer, p *Parser, program string) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, m.render(p.UsageData(program), nil))
+	return err
+}
+
+// WriteTree writes to w the reference of c, followed by the references of each
+// of its descendant commands that is neither hidden nor deprecated, in depth
+// first order. It returns an error when the Level of m is out of range.
+func (m Markdown) WriteTree(w io.Writer, c *Command) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	var b strings.Builder
+	m.writeTree(&b, c)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// level returns the level of the heading of each command.
+func (m Markdown) level() int {
+	if m.Level == 0 {
+		return 1
+	}
+	return m.Level
+}
+
+// validate returns an error when the Level of m is out of range. Markdown has
+// six levels of headings, and the sections of each command use the level that
+// follows the one of the command.
+func (m Markdown) validate() error {
+	if m.Level < 0 || m.Level > 5 {
+		return fmt.Errorf("cannot write Markdown with heading level out of range 1 to 5: %d", m.Level)
+	}
+	return nil
+}
+
+// render returns the reference described by data. When c is not nil, the
+// reference links to the sections of the parent and children of c.
+func (m Markdown) render(data UsageData, c *Command) string {
+	var b strings.Builder
+	heading := strings.Repeat("#", m.level())
+	subheading := heading + "#"
+
+	b.WriteString(`<a id="` + markdownAnchor(data.Program) + `"></a>` + "\n\n")
+	b.WriteString(heading + " " + markdownEscaper.Replace(data.Program) + "\n\n")
+
+	if data.Summary != "" {
+		b.WriteString(markdownEscaper.Replace(data.Summary) + "\n\n")
+	}
+	if data.Description != "" {
+		b.WriteString(markdownEscaper.Replace(data.Description) + "\n\n")
+	}
+	if len(data.Aliases) > 0 {
+		aliases := make([]string, len(data.Aliases))
+		for i, alias := range data.Aliases {
+			aliases[i] = markdownCode(alias)
+		}
+		b.WriteString("Aliases: " + strings.Join(aliases, ", ") + "\n\n")
+	}
+	if data.Deprecated != "" {
+		b.WriteString("> **Deprecated:** " + markdownEscaper.Replace(data.Deprecated) + "\n\n")
+	}
+
+	b.WriteString("```\n" + data.Synopsis + "\n```\n\n")
+
+	if c != nil && c.parent != nil {
+		b.WriteString("Parent: " + markdownLink(c.parent.Path(), c.parent.Path()) + "\n\n")
+	}
+
+	if len(data.Commands) > 0 {
+		b.WriteString(subheading + " Commands\n\n")
+		b.WriteString("| Command | Description |\n| --- | --- |\n")
+		for _, cmd := range data.Commands {
+			name := markdownEscaper.Replace(cmd.Name)
+			if c != nil {
+				if child := c.command(cmd.Name); child != nil && child.listed() {
+					name = markdownLink(cmd.Name, child.Path())
+				}
+			}
+			b.WriteString("| " + name + " | " + markdownCell(cmd.Summary) + " |\n")
+		}
+		b.WriteString("\n")
+	}
+
+	for _, group := range data.Groups {
+		b.WriteString(subheading + " " + markdownEscaper.Replace(group.Name) + "\n\n")
+		b.WriteString("| Option | Default | Environment | Description |\n| --- | --- | --- | --- |\n")
+		for _, opt := range group.Options {
+			flags := strings.Split(opt.Flags, ", ")
+			for i, flag := range flags {
+				flags[i] = markdownCode(flag)
+			}
+			option := strings.Join(flags, ", ")
+			if opt.Metavar != "" {
+				option += " " + markdownCode(opt.Metavar)
+			}
+
+			var def, env string
+			if opt.Required {
+				def = "required"
+			} else if opt.Default != "" {
+				def = markdownCode(opt.Default)
+			}
+			if opt.Env != "" {
+				env = markdownCode(opt.Env)
+			}
+			b.WriteString("| " + option + " | " + def + " | " + env + " | " + markdownCell(opt.Description) + " |\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(data.Files) > 0 {
+		b.WriteString(subheading + " Files\n\n")
+		b.WriteString("| File | Description |\n| --- | --- |\n")
+		for _, file := range data.Files {
+			b.WriteString("| " + markdownCode(file.Path) + " | " + markdownCell(file.Description) + " |\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(data.Examples) > 0 {
+		b.WriteString(subheading + " Examples\n\n")
+		for _, example := range data.Examples {
+			if example.Description != "" {
+				b.WriteString(markdownEscaper.Replace(example.Description) + "\n\n")
+			}
+			b.WriteString("```\n" + example.Command + "\n```\n\n")
+		}
+	}
+
+	return b.String()
+}
+
+// writeTree writes to b the reference of c, followed by the references of its
+// listed descendants.
+func (m Markdown) writeTree(b *strings.Builder, c *Command) {
+	b.WriteString(m.render(c.UsageData(), c))
+	for _, child := range c.children {
+		if child.listed() {
+			m.writeTree(b, child)
+		}
+	}
+}
+
+// markdownEscaper escapes the characters that Markdown would otherwise
+// interpret as formatting in running text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`|`, `\|`,
+)
+
+// markdownAnchor returns the anchor of the section of the program or command
+// at path.
+func markdownAnchor(path string) string {
+	return strings.ToLower(strings.Join(strings.Fields(path), "-"))
+}
+
+// markdownCell returns text escaped to fit in a table cell, which cannot span
+// more than one line.
+func markdownCell(text string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(text), " "))
+}
+
+// markdownCode returns text as a code span, delimited by enough backquotes
+// that none of those in text end it. Pipes are escaped, because tables are
+// split into cells before code spans are recognized.
+func markdownCode(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + strings.ReplaceAll(text, "|", `\|`) + fence
+}
+
+// markdownLink returns a link labeled label to the section of the command at
+// path.
+func markdownLink(label, path string) string {
+	return "[" + markdownEscaper.Replace(label) + "](#" + markdownAnchor(path) + ")"
+}
@@ -0,0 +1,112 @@
+package golf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownWriteParser(t *testing.T) {
+	var p Parser
+	p.WithDescription("Serve files over the network.")
+	p.WithBoolP('v', "verbose", false, "print *verbose* info")
+	p.WithStringP('s', "server", "localhost", "listen on `HOST`")
+	p.WithInt("port", 80, "listen on port")
+	p.WithString("sep", "|", "field separator")
+	p.WithGroup("Networking", "server", "port")
+	p.WithEnv("server", "SERVER")
+	p.WithRequired("port")
+	p.WithPositionals("DIR")
+	p.WithFile("/etc/serve.conf", "Default configuration.")
+	p.WithExample("serve -s example.com /srv", "Serve /srv on example.com.")
+	ensureError(t, p.Err())
+
+	var output strings.Builder
+	ensureError(t, Markdown{Level: 2}.WriteParser(&output, &p, "serve"))
+
+	want := "<a id=\"serve\"></a>\n" +
+		"\n" +
+		"## serve\n" +
+		"\n" +
+		"Serve files over the network.\n" +
+		"\n" +
+		"```\n" +
+		"serve [-v] [-s HOST] --port INT [--sep STRING] DIR\n" +
+		"```\n" +
+		"\n" +
+		"### Options\n" +
+		"\n" +
+		"| Option | Default | Environment | Description |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `-v`, `--verbose` |  |  | print \\*verbose\\* info |\n" +
+		"| `--sep` `STRING` | `\"\\|\"` |  | field separator |\n" +
+		"\n" +
+		"### Networking\n" +
+		"\n" +
+		"| Option | Default | Environment | Description |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `-s`, `--server` `HOST` | `\"localhost\"` | `SERVER` | listen on HOST |\n" +
+		"| `--port` `INT` | required |  | listen on port |\n" +
+		"\n" +
+		"### Files\n" +
+		"\n" +
+		"| File | Description |\n" +
+		"| --- | --- |\n" +
+		"| `/etc/serve.conf` | Default configuration. |\n" +
+		"\n" +
+		"### Examples\n" +
+		"\n" +
+		"Serve /srv on example.com.\n" +
+		"\n" +
+		"```\n" +
+		"serve -s example.com /srv\n" +
+		"```\n" +
+		"\n"
+	if got := output.String(); got != want {
+		t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+	}
+}
+
+func TestMarkdownWriteTree(t *testing.T) {
+	root, _ := newTestTree(t)
+	root.Commands()[0].Hidden = true
+
+	var output strings.Builder
+	ensureError(t, Markdown{}.WriteTree(&output, root))
+	got := output.String()
+
+	for _, want := range []string{
+		"<a id=\"prog\"></a>\n\n# prog\n",
+		"| [bar](#prog-bar) | Do bar things. |\n| help | Show help for a command. |\n",
+		"<a id=\"prog-bar\"></a>\n\n# prog bar\n",
+		"Parent: [prog](#prog)\n",
+		"| [baz](#prog-bar-baz) | Do baz things. |\n",
+		"<a id=\"prog-bar-baz\"></a>\n\n# prog bar baz\n",
+		"Parent: [prog bar](#prog-bar)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("GOT:\n%s\nWANT: %q", got, want)
+		}
+	}
+	if strings.Contains(got, "foo") {
+		t.Errorf("GOT:\n%s\nWANT: no hidden command", got)
+	}
+}
+
+func TestMarkdownLevel(t *testing.T) {
+	root, _ := newTestTree(t)
+
+	var output strings.Builder
+	ensureError(t, Markdown{Level: 5}.WriteTree(&output, root))
+	if got, want := output.String(), "\n\n##### prog\n"; !strings.Contains(got, want) {
+		t.Errorf("GOT:\n%s\nWANT: %q", got, want)
+	}
+
+	for _, level := range []int{-1, 6} {
+		output.Reset()
+		ensureError(t, Markdown{Level: level}.WriteTree(&output, root), "cannot write Markdown with heading level out of range 1 to 5: ")
+		ensureError(t, Markdown{Level: level}.WriteParser(&output, &root.Parser, "prog"), "cannot write Markdown with heading level out of range 1 to 5: ")
+		if got := output.String(); got != "" {
+			t.Errorf("GOT: %q; WANT: %q", got, "")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package golf
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ExecuteMultiCall invokes ExecuteMultiCallContext with context.Background.
+func (c *Command) ExecuteMultiCall(argv []string) error {
+	return c.ExecuteMultiCallContext(context.Background(), argv)
+}
+
+// ExecuteMultiCallContext runs c as a multi-call program, the way busybox
+// does, where argv is the complete command line including the name of the
+// program, such as os.Args. When the base name of argv[0] is the name or an
+// alias of a child command of c, such as when the program is invoked through a
+// symbolic link named after the child command, it executes that child command
+// with the remaining arguments. Otherwise, such as when the program is invoked
+// under its primary name, it executes c with the remaining arguments, which
+// select a child command as usual. The Name of c ought to be set, because the
+// Path of a root command without a Name is derived from os.Args[0].
+func (c *Command) ExecuteMultiCallContext(ctx context.Context, argv []string) error {
+	if len(argv) == 0 {
+		return c.ExecuteContext(ctx, nil)
+	}
+	args := argv[1:]
+
+	if name := multiCallName(argv[0]); name != c.Name && name != helpCommandName && c.command(name) != nil {
+		args = append([]string{name}, args...)
+	}
+	return c.ExecuteContext(ctx, args)
+}
+
+// MultiCallNames returns the names under which a multi-call program may invoke
+// a child command of c, which are the names and aliases of its child commands,
+// excluding hidden and deprecated commands. These are the names of the
+// symbolic links to install that point to the program.
+func (c *Command) MultiCallNames() []string {
+	var names []string
+	for _, child := range c.children {
+		if child.listed() {
+			names = append(names, child.Name)
+			names = append(names, child.Aliases...)
+		}
+	}
+	return names
+}
+
+// multiCallName returns the base name of the pathname of a program, without
+// the ".exe" extension on Windows.
+func multiCallName(pathname string) string {
+	name := filepath.Base(pathname)
+	if runtime.GOOS == "windows" {
+		if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+			name = name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
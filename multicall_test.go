@@ -0,0 +1,59 @@
+package golf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandExecuteMultiCall(t *testing.T) {
+	t.Run("primary name", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		ensureError(t, root.ExecuteMultiCall([]string{"/usr/bin/prog", "foo", "some"}))
+		ensureStringSlicesMatch(t, *ran, []string{"foo some"})
+	})
+
+	t.Run("child name", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		ensureError(t, root.ExecuteMultiCall([]string{"/usr/local/bin/foo", "-b", "some"}))
+		ensureStringSlicesMatch(t, *ran, []string{"foo some"})
+	})
+
+	t.Run("alias name", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Commands()[0].Aliases = []string{"f"}
+		ensureError(t, root.ExecuteMultiCall([]string{"f", "some"}))
+		ensureStringSlicesMatch(t, *ran, []string{"foo some"})
+	})
+
+	t.Run("nested child", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		ensureError(t, root.ExecuteMultiCall([]string{"bar", "baz", "-i", "13", "some"}))
+		ensureStringSlicesMatch(t, *ran, []string{"bar baz some"})
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		ensureError(t, root.ExecuteMultiCall([]string{"prog-v2", "foo", "some"}))
+		ensureStringSlicesMatch(t, *ran, []string{"foo some"})
+	})
+
+	t.Run("child name does not dispatch again", func(t *testing.T) {
+		root, ran := newTestTree(t)
+		root.Output = new(strings.Builder)
+		ensureError(t, root.ExecuteMultiCall([]string{"foo", "foo"}))
+		ensureStringSlicesMatch(t, *ran, []string{"foo foo"})
+	})
+}
+
+func TestCommandMultiCallNames(t *testing.T) {
+	root, _ := newTestTree(t)
+	root.Commands()[0].Aliases = []string{"f"}
+	root.AddCommand(&Command{Name: "secret", Hidden: true}, &Command{Name: "old", Deprecated: "no longer maintained"})
+
+	ensureStringSlicesMatch(t, root.MultiCallNames(), []string{"foo", "f", "bar"})
+}
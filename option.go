@@ -1,6 +1,10 @@
 package golf
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // option is list of methods any concrete option needs to have for use by
 // parser.
@@ -11,9 +15,43 @@ type option interface {
 	NextSlurp() slurpType // next state for state machine
 	Short() string        // short flag
 
+	attributes() *optionAttributes // settings not provided when declared
+	reset()                        // restores the default value
 }
 
+// optionAttributes holds the settings common to every concrete option that are
+// configured after the option has been declared.
+type optionAttributes struct {
+	repeatPolicy    RepeatPolicy // how to treat repeated occurrences
+	hasRepeatPolicy bool         // when false, the parser's policy applies
+	maxOccurrences  int          // when positive, maximum occurrences allowed
+	group           string       // name of the group displayed with, if any
+	metavar         string       // placeholder for the argument in usage
+	hidden          bool         // when true, omitted from usage
+	deprecated      string       // when not empty, warning displayed on use
+	required        bool         // when true, Parse fails when not provided
+	env             string       // name of environment variable, if any
+}
+
+func (a *optionAttributes) attributes() *optionAttributes { return a }
+
+// optionAlias is a deprecated flag that forwards to another option.
+type optionAlias struct {
+	optionAttributes
+	target option
+	long   string
+	short  string
+}
+
+func (o optionAlias) Default() interface{} { return o.target.Default() }
+func (o optionAlias) Description() string  { return o.target.Description() }
+func (o optionAlias) Long() string         { return o.long }
+func (o optionAlias) NextSlurp() slurpType { return o.target.NextSlurp() }
+func (o optionAlias) Short() string        { return o.short }
+func (o optionAlias) reset()               {}
+
 type optionBool struct {
+	optionAttributes
 	pv          *bool
 	description string
 	long        string
@@ -26,8 +64,10 @@ func (o optionBool) Description() string  { return o.description }
 func (o optionBool) Long() string         { return o.long }
 func (o optionBool) NextSlurp() slurpType { return nothingToSlurp }
 func (o optionBool) Short() string        { return o.short }
+func (o optionBool) reset()               { *o.pv = o.def }
 
 type optionDuration struct {
+	optionAttributes
 	pv          *time.Duration
 	description string
 	long        string
@@ -40,8 +80,10 @@ func (o optionDuration) Description() string  { return o.description }
 func (o optionDuration) Long() string         { return o.long }
 func (o optionDuration) NextSlurp() slurpType { return slurpDuration }
 func (o optionDuration) Short() string        { return o.short }
+func (o optionDuration) reset()               { *o.pv = o.def }
 
 type optionFloat struct {
+	optionAttributes
 	pv          *float64
 	description string
 	long        string
@@ -54,8 +96,10 @@ func (o optionFloat) Description() string  { return o.description }
 func (o optionFloat) Long() string         { return o.long }
 func (o optionFloat) NextSlurp() slurpType { return slurpFloat }
 func (o optionFloat) Short() string        { return o.short }
+func (o optionFloat) reset()               { *o.pv = o.def }
 
 type optionInt struct {
+	optionAttributes
 	pv          *int
 	description string
 	long        string
@@ -68,8 +112,10 @@ func (o optionInt) Description() string  { return o.description }
 func (o optionInt) Long() string         { return o.long }
 func (o optionInt) NextSlurp() slurpType { return slurpInt }
 func (o optionInt) Short() string        { return o.short }
+func (o optionInt) reset()               { *o.pv = o.def }
 
 type optionInt64 struct {
+	optionAttributes
 	pv          *int64
 	description string
 	long        string
@@ -82,8 +128,10 @@ func (o optionInt64) Description() string  { return o.description }
 func (o optionInt64) Long() string         { return o.long }
 func (o optionInt64) NextSlurp() slurpType { return slurpInt64 }
 func (o optionInt64) Short() string        { return o.short }
+func (o optionInt64) reset()               { *o.pv = o.def }
 
 type optionString struct {
+	optionAttributes
 	pv          *string
 	description string
 	long        string
@@ -96,8 +144,10 @@ func (o optionString) Description() string  { return o.description }
 func (o optionString) Long() string         { return o.long }
 func (o optionString) NextSlurp() slurpType { return slurpString }
 func (o optionString) Short() string        { return o.short }
+func (o optionString) reset()               { *o.pv = o.def }
 
 type optionUint struct {
+	optionAttributes
 	pv          *uint
 	description string
 	long        string
@@ -110,8 +160,10 @@ func (o optionUint) Description() string  { return o.description }
 func (o optionUint) Long() string         { return o.long }
 func (o optionUint) NextSlurp() slurpType { return slurpUint }
 func (o optionUint) Short() string        { return o.short }
+func (o optionUint) reset()               { *o.pv = o.def }
 
 type optionUint64 struct {
+	optionAttributes
 	pv          *uint64
 	description string
 	long        string
@@ -124,3 +176,35 @@ func (o optionUint64) Description() string  { return o.description }
 func (o optionUint64) Long() string         { return o.long }
 func (o optionUint64) NextSlurp() slurpType { return slurpUint64 }
 func (o optionUint64) Short() string        { return o.short }
+func (o optionUint64) reset()               { *o.pv = o.def }
+
+// metavar returns the placeholder that stands for the argument of opt in the
+// usage message, along with the description of opt with its backquotes
+// removed. Like the "flag" standard library package, the placeholder is the
+// first word of the description enclosed in backquotes, such as "HOST" in
+// "connect to `HOST`". A placeholder configured by WithMetavar takes
+// precedence, and the name of the type of the option in upper case, such as
+// "INT" or "DURATION" for a time.Duration, is the fallback. Boolean options,
+// which do not take an argument, have no placeholder.
+func metavar(opt option) (string, string) {
+	name, description := opt.attributes().metavar, opt.Description()
+
+	if i := strings.IndexByte(description, '`'); i >= 0 {
+		if j := strings.IndexByte(description[i+1:], '`'); j >= 0 {
+			j += i + 1
+			if name == "" {
+				name = description[i+1 : j]
+			}
+			description = description[:i] + description[i+1:j] + description[j+1:]
+		}
+	}
+
+	if _, ok := opt.Default().(bool); ok {
+		return "", description
+	}
+	if name == "" {
+		name = fmt.Sprintf("%T", opt.Default())
+		name = strings.ToUpper(name[strings.LastIndexByte(name, '.')+1:])
+	}
+	return name, description
+}
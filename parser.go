@@ -5,17 +5,178 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
+	"text/template"
+	"unicode"
 	"unicode/utf8"
 )
 
+// noDeprecationWarningsEnv is the name of the environment variable that, when
+// not empty, silences deprecation messages.
+const noDeprecationWarningsEnv = "GOLF_NO_DEPRECATION_WARNINGS"
+
+// showHiddenEnv is the name of the environment variable that, when not empty,
+// reveals hidden options in help, but not in generated documentation.
+const showHiddenEnv = "GOLF_SHOW_HIDDEN"
+
+// ErrHelp is the error returned by Parse when the "-h" or "--help" flag is
+// provided but the program has not declared an option with that flag.
+var ErrHelp = errors.New("help requested")
+
+// SingleHyphenMode determines how a Parser treats a single-hyphen argument
+// whose text matches the name of a long flag, such as "-limit".
+type SingleHyphenMode uint
+
+const (
+	// SingleHyphenShortOnly treats every rune after a single hyphen as a
+	// short flag. This is the default mode.
+	SingleHyphenShortOnly SingleHyphenMode = iota
+
+	// SingleHyphenLongAllowed accepts a single hyphen followed by the entire
+	// name of a long flag, for compatibility with the "flag" standard library
+	// package.
+	SingleHyphenLongAllowed
+
+	// SingleHyphenLongDeprecated accepts a single hyphen followed by the
+	// entire name of a long flag, like SingleHyphenLongAllowed, but writes a
+	// deprecation warning each time the form is used.
+	SingleHyphenLongDeprecated
+)
+
+// PrefixKind determines the meaning of a rune that introduces options when it
+// is the first rune of a command line argument.
+type PrefixKind uint
+
+const (
+	// PrefixNone means the rune does not introduce options, and arguments
+	// that start with it are ordinary arguments.
+	PrefixNone PrefixKind = iota
+
+	// PrefixOption means the rune introduces options, like the hyphen. A
+	// single prefix rune is followed by one or more short flags, and a
+	// doubled prefix rune is followed by a long flag name. A doubled prefix
+	// rune by itself terminates option processing.
+	PrefixOption
+
+	// PrefixNegate means the rune introduces boolean options that are turned
+	// off, like the plus sign in "set +x". A single prefix rune is followed by
+	// one or more short flags, and a doubled prefix rune is followed by a long
+	// flag name. Using it with an option that is not boolean is an error.
+	PrefixNegate
+)
+
+// prefixNoun returns the word used in messages to describe the prefix rune.
+func prefixNoun(r rune) string {
+	if r == '-' {
+		return "hyphen"
+	}
+	return "prefix"
+}
+
+// RepeatPolicy determines how a Parser treats an option that occurs more than
+// once on the command line.
+type RepeatPolicy uint
+
+const (
+	// RepeatLastWins keeps the value of the final occurrence of an option.
+	// This is the default policy.
+	RepeatLastWins RepeatPolicy = iota
+
+	// RepeatFirstWins keeps the value of the first occurrence of an option,
+	// and ignores the values of subsequent occurrences.
+	RepeatFirstWins
+
+	// RepeatError causes Parse to return an error when an option occurs more
+	// than once.
+	RepeatError
+)
+
+// HelpLayout determines how a Parser displays its options in usage messages.
+type HelpLayout uint
+
+const (
+	// HelpLayoutStacked displays the flags of each option on their own line,
+	// followed by its description on the lines below. This is the default
+	// layout.
+	HelpLayoutStacked HelpLayout = iota
+
+	// HelpLayoutColumns displays the flags of each option in a left column,
+	// and its description in a right column, aligned across all options and
+	// wrapped with a hanging indent. When the flags would take more than half
+	// the width of the usage message, options are displayed as with
+	// HelpLayoutStacked.
+	HelpLayoutColumns
+)
+
+// Normalizer returns the canonical form of a long flag name. When a Parser has
+// a Normalizer, two long flag names are considered the same flag when their
+// canonical forms are equal.
+type Normalizer func(name string) string
+
+// NormalizeCase is a Normalizer that folds long flag names to lower case, so
+// "--DryRun" and "--dryrun" select the same flag.
+func NormalizeCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// NormalizeSeparators is a Normalizer that treats underscores and hyphens in
+// long flag names as equivalent, so "--dry_run" and "--dry-run" select the same
+// flag.
+func NormalizeSeparators(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// NormalizeLoose is a Normalizer that folds long flag names to lower case and
+// ignores underscores and hyphens, so "--DryRun", "--dry_run", and "--dry-run"
+// all select the same flag.
+func NormalizeLoose(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// occurrence records where an option was found on the command line.
+type occurrence struct {
+	flag  string // flag as provided, including its prefix
+	index int    // one-based position of the argument that provided the flag
+}
+
+func (o occurrence) String() string {
+	return fmt.Sprintf("%q at argument %d", o.flag, o.index)
+}
+
 // Parser can parse a series of command line arguments.
 type Parser struct {
 	options            []option
+	inherited          []option // options declared elsewhere that are also recognized
 	remainingArguments []string // keep track of remaining arguments
 	err                error
-	argsProcessed      int  // keep track of how many arguments have been set
-	parsed             bool // keep track of whether command line arguments have been parsed
+	warnings           io.Writer               // where warnings are written; when nil, os.Stderr
+	normalize          Normalizer              // when not nil, canonicalizes long flag names
+	prefixes           map[rune]PrefixKind     // when nil, only hyphen introduces options
+	singleHyphen       SingleHyphenMode        // how to treat "-name" when name is a long flag
+	repeatPolicy       RepeatPolicy            // how to treat repeated options
+	helpLayout         HelpLayout              // how to display options in usage message
+	strictHyphen       bool                    // when true, a lone hyphen is an error
+	stopAtArgument     bool                    // when true, parsing stops at the first argument
+	occurrences        map[option][]occurrence // where each option was found during Parse
+	deprecationsWarned map[option]struct{}     // deprecated options already warned about during Parse
+	argsProcessed      int                     // keep track of how many arguments have been set
+	parsed             bool                    // keep track of whether command line arguments have been parsed
+	parseFailed        bool                    // true when err was returned by Parse
+	groups             []string                // names of option groups, in declaration order
+	showHidden         bool                    // when true, hidden options are displayed
+	positionals        []string                // names of positional arguments displayed in synopsis
+	columns            int                     // when positive, width of usage message
+	widthProvider      WidthProvider           // when not nil, provides width of usage message
+	description        string                  // description displayed in usage message
+	examples           []UsageExample          // examples displayed in usage message
+	files              []UsageFile             // files described in man pages
+	usageTemplate      *template.Template      // when not nil, renders usage message
 }
 
 // Arg returns the i'th command-line argument. Arg(0) is the first remaining
@@ -33,6 +194,42 @@ func (p *Parser) Args() []string {
 	return p.remainingArguments
 }
 
+// displayed returns true when opt ought to be displayed in the usage message,
+// which is when it is neither hidden nor deprecated, or when hidden options are
+// revealed. The environment only reveals hidden options when interactive,
+// which is when displaying help rather than generating documentation.
+func (p *Parser) displayed(opt option, interactive bool) bool {
+	if attrs := opt.attributes(); !attrs.hidden && attrs.deprecated == "" {
+		return true
+	}
+	return p.showHidden || (interactive && os.Getenv(showHiddenEnv) != "")
+}
+
+// displayFlag returns the short flag of opt, or its long flag when it has no
+// short flag, preceded by the prefix used when displaying flags.
+func (p *Parser) displayFlag(opt option) string {
+	prefix := string(p.displayPrefix())
+	if short := opt.Short(); short != "" {
+		return prefix + short
+	}
+	return prefix + prefix + opt.Long()
+}
+
+// displayPrefix returns the prefix rune used when displaying flags, which is
+// the hyphen unless the hyphen no longer introduces options.
+func (p *Parser) displayPrefix() rune {
+	if p.prefixKind('-') == PrefixOption {
+		return '-'
+	}
+	prefix := '-'
+	for r, kind := range p.prefixes {
+		if kind == PrefixOption && (prefix == '-' || r < prefix) {
+			prefix = r
+		}
+	}
+	return prefix
+}
+
 // ensureNoRedefinition ensures the specified single and double hyphen prefix
 // options do not redefine any existing option definitions.
 func (p *Parser) ensureNoRedefinition(short string, long string) error {
@@ -40,6 +237,9 @@ func (p *Parser) ensureNoRedefinition(short string, long string) error {
 		if long != "" && opt.Long() == long {
 			return fmt.Errorf("cannot add option that duplicates long flag: %q", long)
 		}
+		if long != "" && opt.Long() != "" && p.sameLong(opt.Long(), long) {
+			return fmt.Errorf("cannot add option that collides with long flag: %q and %q", long, opt.Long())
+		}
 		if short != "" && opt.Short() == short {
 			return fmt.Errorf("cannot add option that duplicates short flag: %q", short)
 		}
@@ -47,11 +247,109 @@ func (p *Parser) ensureNoRedefinition(short string, long string) error {
 	return nil
 }
 
+// ensureNormalizable ensures the specified long flag name has a usable
+// canonical form when the parser has a Normalizer.
+func (p *Parser) ensureNormalizable(long string) error {
+	if p.normalize == nil {
+		return nil
+	}
+	switch canonical := p.normalize(long); {
+	case canonical == "":
+		return fmt.Errorf("cannot use flag that normalizes to empty string: %q", long)
+	case strings.HasPrefix(canonical, "-"):
+		return fmt.Errorf("cannot use flag that normalizes to start with a hyphen: %q", long)
+	}
+	return nil
+}
+
+// ensureNotGlobal ensures opt does not redefine the flags of any of the
+// specified global options, comparing long flags after normalization when p
+// has a Normalizer.
+func (p *Parser) ensureNotGlobal(opt option, globals []option) error {
+	for _, global := range globals {
+		if long := opt.Long(); long != "" && global.Long() != "" && p.sameLong(global.Long(), long) {
+			return fmt.Errorf("cannot add option that duplicates global long flag: %q", long)
+		}
+		if short := opt.Short(); short != "" && global.Short() == short {
+			return fmt.Errorf("cannot add option that duplicates global short flag: %q", short)
+		}
+	}
+	return nil
+}
+
+// ensureRequired returns an error when any of options is declared as required
+// but was not provided during the most recent Parse of any of parsers. An
+// option inherited by the Parser of a command may be provided to the Parser of
+// any command from the one that declares it to the selected one.
+func (p *Parser) ensureRequired(options []option, parsers ...*Parser) error {
+	for _, opt := range options {
+		if !opt.attributes().required {
+			continue
+		}
+		provided := slices.ContainsFunc(parsers, func(parser *Parser) bool {
+			_, ok := parser.occurrences[opt]
+			return ok
+		})
+		if !provided {
+			return fmt.Errorf("missing required flag: %q", p.displayFlag(opt))
+		}
+	}
+	return nil
+}
+
 // Err returns the error state of a parser.
 func (p *Parser) Err() error {
 	return p.err
 }
 
+// occurred records an occurrence of option f, provided by the flag in the
+// argument at index ai, and applies the repeat policy and maximum occurrence
+// count of the option. When f is deprecated, it warns the first time f occurs,
+// and when f is a deprecated alias, the occurrence is recorded for the option
+// it forwards to instead. It returns the option that receives the value, true
+// when the value provided by this occurrence ought to be ignored, or an error
+// when the occurrence is not allowed.
+func (p *Parser) occurred(f option, ai int, flag string) (option, bool, error) {
+	if message := f.attributes().deprecated; message != "" {
+		if _, ok := p.deprecationsWarned[f]; !ok {
+			p.deprecationsWarned[f] = struct{}{}
+			p.warnDeprecated("flag is deprecated: %q; %s", flag, message)
+		}
+	}
+	if alias, ok := f.(*optionAlias); ok {
+		f = alias.target
+	}
+
+	this := occurrence{flag: flag, index: ai + 1}
+	previous := p.occurrences[f]
+	p.occurrences[f] = append(previous, this)
+
+	attrs := f.attributes()
+	if max := attrs.maxOccurrences; max > 0 && len(previous) >= max {
+		if max == 1 {
+			return f, false, fmt.Errorf("option allows only one occurrence: %s and %s", previous[0], this)
+		}
+		return f, false, fmt.Errorf("option allows at most %d occurrences: %s and %s", max, previous[0], this)
+	}
+	if len(previous) == 0 {
+		return f, false, nil
+	}
+
+	policy := p.repeatPolicy
+	if attrs.hasRepeatPolicy {
+		policy = attrs.repeatPolicy
+	}
+
+	switch policy {
+	case RepeatFirstWins:
+		return f, true, nil
+	case RepeatError:
+		return f, false, fmt.Errorf("option repeated: %s and %s", previous[len(previous)-1], this)
+	default:
+		return f, false, nil
+	}
+}
+
 // optionFromDoubleHyphenPrefix performs linear search for the option with a
 // matching double-hyphen prefix in the list of options. It returns the option
 // found, or nil if the requested name was not found.
@@ -60,7 +358,27 @@ func (p *Parser) optionFromDoubleHyphenPrefix(long string) option {
 		return nil
 	}
 	for _, option := range p.options {
-		if option.Long() == long {
+		if p.sameLong(option.Long(), long) {
+			return option
+		}
+	}
+	for _, option := range p.inherited {
+		if p.sameLong(option.Long(), long) {
+			return option
+		}
+	}
+	return nil
+}
+
+// optionFromFlag performs linear search for the option with a matching short
+// or long flag in the list of options. It returns the option found, or nil if
+// the requested flag was not found.
+func (p *Parser) optionFromFlag(flag string) option {
+	if flag == "" {
+		return nil
+	}
+	for _, option := range p.options {
+		if option.Short() == flag || (option.Long() != "" && p.sameLong(option.Long(), flag)) {
 			return option
 		}
 	}
@@ -80,9 +398,28 @@ func (p *Parser) optionFromSingleHyphenPrefix(short rune) option {
 			return option
 		}
 	}
+	for _, option := range p.inherited {
+		if option.Short() == s {
+			return option
+		}
+	}
 	return nil
 }
 
+// optionToConfigure returns the option selected by flag, or an error when flag
+// does not select an option, or selects a deprecated alias, which has no
+// attributes of its own because it forwards to another option.
+func (p *Parser) optionToConfigure(flag string) (option, error) {
+	f := p.optionFromFlag(flag)
+	if f == nil {
+		return nil, fmt.Errorf("cannot configure unknown flag: %q", flag)
+	}
+	if _, ok := f.(*optionAlias); ok {
+		return nil, fmt.Errorf("cannot configure deprecated alias: %q", flag)
+	}
+	return f, nil
+}
+
 // NArg returns the number of arguments remaining after flags have been
 // processed.
 func (p *Parser) NArg() int {
@@ -94,26 +431,49 @@ func (p *Parser) NFlag() int {
 	return p.argsProcessed
 }
 
-// Parse parses args.
+// Parse parses args. It returns an error when an option declared as required
+// by WithRequired was not provided.
 func (p *Parser) Parse(args []string) error {
 	if p.err != nil {
 		return p.err // cannot parse when in state of error
 	}
+	defer func() { p.parseFailed = p.err != nil }()
 
+	if p.err = p.parse(args); p.err == nil {
+		p.err = p.ensureRequired(p.options, p)
+	}
+	return p.err
+}
+
+// parse parses args as described by Parse, except for ensuring each required
+// option was provided.
+func (p *Parser) parse(args []string) error {
 	// Reset parser.
 	p.argsProcessed = 0
 	p.remainingArguments = p.remainingArguments[:0]
 	p.parsed = false
+	if p.occurrences == nil {
+		p.occurrences = make(map[option][]occurrence)
+		p.deprecationsWarned = make(map[option]struct{})
+	} else {
+		clear(p.occurrences)
+		clear(p.deprecationsWarned)
+	}
 
 	var flagType slurpType
 	var flagName, flagText string
 	var f option
+	var skip bool   // when true, ignore the value of the current option
+	var prefix rune // prefix rune that introduced the current argument
+	var negate bool // when true, the current argument turns boolean options off
 
 	for ai, arg := range args { // ai (arg index)
 		debug("arg %d: %q; start argParserState: %v\n", ai, arg, flagType)
 
 		if flagType != nothingToSlurp {
-			p.err = slurpText(arg, flagType, f)
+			if !skip {
+				p.err = slurpText(arg, flagType, f)
+			}
 			if p.err != nil {
 				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
 				return p.err
@@ -144,8 +504,9 @@ func (p *Parser) Parse(args []string) error {
 				debug("  LONG FLAG NAME: %q\n", flagName)
 				break // out of parsing this arg
 			} else if runeParserState == beginArgument {
-				if r != '-' {
-					if false {
+				kind := p.prefixKind(r)
+				if kind == PrefixNone {
+					if p.stopAtArgument {
 						debug("index: %d; this rune ends processing: %q\n", ai, r)
 						p.parsed = true
 						p.remainingArguments = append(p.remainingArguments, args[ai:]...)
@@ -154,20 +515,52 @@ func (p *Parser) Parse(args []string) error {
 					runeParserState = wantArgument
 					break // out of parsing this arg
 				}
-				runeParserState = consumedHyphen
-			} else if runeParserState == consumedHyphen {
+				prefix = r
+				negate = kind == PrefixNegate
+				runeParserState = consumedPrefix
+			} else if runeParserState == consumedPrefix {
+				// When permitted, a single prefix followed by the entire name
+				// of a long flag selects that long flag. Names of a single
+				// rune always select a short flag, so a long match only takes
+				// precedence over a cluster of short flags.
+				if r != prefix && p.singleHyphen != SingleHyphenShortOnly {
+					if name := arg[bi:]; utf8.RuneCountInString(name) > 1 && p.optionFromDoubleHyphenPrefix(name) != nil {
+						debug("  SINGLE PREFIX LONG FLAG NAME: %q\n", name)
+						if p.singleHyphen == SingleHyphenLongDeprecated {
+							p.warnDeprecated("single-%s long flag is deprecated: %q; use %q", prefixNoun(prefix), arg, string(prefix)+arg)
+						}
+						flagName = name
+						runeParserState = wantLongName
+						break // out of parsing this arg
+					}
+				}
 				switch r {
-				case '-':
+				case prefix:
 					runeParserState = wantLongName
 				default:
 					if f = p.optionFromSingleHyphenPrefix(r); f == nil {
 						p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+						if r == 'h' && !negate {
+							p.err = ErrHelp
+							return p.err
+						}
 						p.err = fmt.Errorf("unknown flag: %q", r)
 						return p.err
 					}
+					if f, skip, p.err = p.occurred(f, ai, fmt.Sprintf("%c%c", prefix, r)); p.err != nil {
+						p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+						return p.err
+					}
+					if negate && f.NextSlurp() != nothingToSlurp {
+						p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+						p.err = fmt.Errorf("cannot negate flag that is not boolean: %q", r)
+						return p.err
+					}
 					switch flagType = f.NextSlurp(); flagType {
 					case nothingToSlurp:
-						*f.(*optionBool).pv = true
+						if !skip {
+							*f.(*optionBool).pv = !negate
+						}
 						runeParserState = wantShortFlagsOnly
 					default:
 						runeParserState = wantText
@@ -176,12 +569,27 @@ func (p *Parser) Parse(args []string) error {
 			} else if runeParserState == wantShortFlagsOnly {
 				if f = p.optionFromSingleHyphenPrefix(r); f == nil {
 					p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+					if r == 'h' && !negate {
+						p.err = ErrHelp
+						return p.err
+					}
 					p.err = fmt.Errorf("unknown flag: %q", r)
 					return p.err
 				}
+				if f, skip, p.err = p.occurred(f, ai, fmt.Sprintf("%c%c", prefix, r)); p.err != nil {
+					p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+					return p.err
+				}
+				if negate && f.NextSlurp() != nothingToSlurp {
+					p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+					p.err = fmt.Errorf("cannot negate flag that is not boolean: %q", r)
+					return p.err
+				}
 				switch flagType = f.NextSlurp(); flagType {
 				case nothingToSlurp:
-					*f.(*optionBool).pv = true
+					if !skip {
+						*f.(*optionBool).pv = !negate
+					}
 				default:
 					runeParserState = wantText
 				}
@@ -193,10 +601,21 @@ func (p *Parser) Parse(args []string) error {
 		debug("  POST: runeParserState: %v\n", runeParserState)
 
 		switch runeParserState {
-		case consumedHyphen:
-			p.remainingArguments = append(p.remainingArguments, args[ai:]...)
-			p.err = errors.New("hyphen without flags")
-			return p.err
+		case consumedPrefix:
+			if p.strictHyphen {
+				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+				p.err = fmt.Errorf("%s without flags", prefixNoun(prefix))
+				return p.err
+			}
+			// A lone hyphen conventionally refers to standard input or
+			// standard output, and is an ordinary argument, as is any other
+			// lone prefix.
+			if p.stopAtArgument {
+				p.parsed = true
+				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+				return nil
+			}
+			p.remainingArguments = append(p.remainingArguments, arg)
 		case wantArgument:
 			p.remainingArguments = append(p.remainingArguments, arg)
 		case wantText:
@@ -209,7 +628,9 @@ func (p *Parser) Parse(args []string) error {
 			if flagType == nothingToSlurp {
 				panic(fmt.Errorf("got text %q but invalid nextSlurp: %v", flagText, flagType))
 			}
-			p.err = slurpText(flagText, flagType, f)
+			if !skip {
+				p.err = slurpText(flagText, flagType, f)
+			}
 			if p.err != nil {
 				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
 				return p.err
@@ -221,6 +642,16 @@ func (p *Parser) Parse(args []string) error {
 			p.argsProcessed++
 		case wantLongName:
 			if flagName == "" {
+				if negate {
+					// doubled negation prefix is an ordinary argument
+					if p.stopAtArgument {
+						p.parsed = true
+						p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+						return nil
+					}
+					p.remainingArguments = append(p.remainingArguments, arg)
+					continue // with next arg
+				}
 				// double-hyphen: remaining arguments everything after this
 				p.argsProcessed++
 				p.parsed = true
@@ -228,13 +659,26 @@ func (p *Parser) Parse(args []string) error {
 				return nil
 			}
 			if f = p.optionFromDoubleHyphenPrefix(flagName); f == nil {
+				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+				if p.sameLong(flagName, "help") && !negate {
+					p.err = ErrHelp
+					return p.err
+				}
 				p.err = fmt.Errorf("unknown flag: %q", flagName)
+				return p.err
+			}
+			if f, skip, p.err = p.occurred(f, ai, arg); p.err != nil {
+				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
+				return p.err
+			}
+			if negate && f.NextSlurp() != nothingToSlurp {
+				p.err = fmt.Errorf("cannot negate flag that is not boolean: %q", flagName)
 				p.remainingArguments = append(p.remainingArguments, args[ai:]...)
 				return p.err
 			}
 			flagName = "" // reset
-			if flagType = f.NextSlurp(); flagType == nothingToSlurp {
-				*f.(*optionBool).pv = true
+			if flagType = f.NextSlurp(); flagType == nothingToSlurp && !skip {
+				*f.(*optionBool).pv = !negate
 			}
 			p.argsProcessed++
 		default:
@@ -267,18 +711,22 @@ func (p *Parser) Parse(args []string) error {
 // parseShortAndLongFlag is called when there is both a short and a long flag to
 // validate and ensure there are no duplicates.
 func (p *Parser) parseShortAndLongFlag(short rune, long string) error {
-	switch short {
-	case utf8.RuneError:
+	switch {
+	case short == utf8.RuneError:
 		return fmt.Errorf("cannot use flag with invalid rune: %q", short)
-	case '-':
-		return fmt.Errorf("cannot use hyphen as a flag: %q", short)
+	case short == '-' || p.prefixKind(short) != PrefixNone:
+		return fmt.Errorf("cannot use %s as a flag: %q", prefixNoun(short), short)
 	}
 
-	switch {
-	case long == "":
+	if long == "" {
 		return errors.New("cannot use empty flag string")
-	case strings.HasPrefix(long, "-"):
-		return fmt.Errorf("cannot use flag that starts with a hyphen: %q", long)
+	}
+	if r, _ := utf8.DecodeRuneInString(long); r == '-' || p.prefixKind(r) != PrefixNone {
+		return fmt.Errorf("cannot use flag that starts with a %s: %q", prefixNoun(r), long)
+	}
+
+	if err := p.ensureNormalizable(long); err != nil {
+		return err
 	}
 
 	return p.ensureNoRedefinition(fmt.Sprintf("%c", short), long)
@@ -305,8 +753,8 @@ func (p *Parser) parseSingleFlag(flag string) (string, string, error) {
 			return thisRuneString, "", fmt.Errorf("cannot use flag with invalid rune: %q", flag)
 		}
 		if runeCount == 0 {
-			if thisRune == '-' {
-				return thisRuneString, "", fmt.Errorf("cannot use flag that starts with a hyphen: %q", flag)
+			if thisRune == '-' || p.prefixKind(thisRune) != PrefixNone {
+				return thisRuneString, "", fmt.Errorf("cannot use flag that starts with a %s: %q", prefixNoun(thisRune), flag)
 			}
 			firstRune = thisRuneString
 		}
@@ -317,6 +765,9 @@ func (p *Parser) parseSingleFlag(flag string) (string, string, error) {
 	if runeCount == 1 {
 		return firstRune, "", p.ensureNoRedefinition(firstRune, "")
 	}
+	if err := p.ensureNormalizable(flag); err != nil {
+		return "", flag, err
+	}
 	return "", flag, p.ensureNoRedefinition("", flag)
 }
 
@@ -325,6 +776,18 @@ func (p Parser) Parsed() bool {
 	return p.parsed
 }
 
+// prefixKind returns the meaning of r when it is the first rune of a command
+// line argument.
+func (p *Parser) prefixKind(r rune) PrefixKind {
+	if p.prefixes == nil {
+		if r == '-' {
+			return PrefixOption
+		}
+		return PrefixNone
+	}
+	return p.prefixes[r]
+}
+
 // PrintDefaults prints to standard error, a usage message showing the default
 // settings of all defined command-line flags.
 func (p *Parser) PrintDefaults() {
@@ -334,37 +797,515 @@ func (p *Parser) PrintDefaults() {
 // PrintDefaultsTo prints to w, a usage message showing the default settings of
 // all defined command-line flags.
 func (p *Parser) PrintDefaultsTo(w io.Writer) {
+	p.printOptions(w, "", p.options)
+}
+
+// printOptions prints to w, a usage message showing the default settings of
+// the specified options, grouped as described by usageGroups. The options
+// without a group are printed first, below heading when it is not empty.
+func (p *Parser) printOptions(w io.Writer, heading string, options []option) {
+	data := UsageData{
+		Groups: p.usageGroups(heading, options, true),
+		Layout: p.helpLayout,
+		Width:  p.width(),
+	}
+	var wrote bool
+	for _, group := range data.Groups {
+		if wrote {
+			fmt.Fprintln(w)
+		}
+		if group.Name != "" {
+			fmt.Fprintf(w, "%s:\n", group.Name)
+		}
+		fmt.Fprint(w, data.FormatOptions(group.Options))
+		wrote = true
+	}
+}
+
+// reset restores the default value of each option declared by p, and clears
+// the error from the most recent call to Parse, so that p may parse another
+// series of command line arguments. Errors from declaring options remain.
+func (p *Parser) reset() {
 	for _, opt := range p.options {
-		var def, typeName string
-		description := opt.Description()
-		value := opt.Default()
-
-		switch value.(type) {
-		case bool:
-			typeName = "" // do not want to add a default blob when boolean
-		case string, rune:
-			def = fmt.Sprintf(" (default: %q)", value)
-			typeName = fmt.Sprintf(" %T", value)
-		default:
-			def = fmt.Sprintf(" (default: %v)", value)
-			typeName = fmt.Sprintf(" %T", value)
+		opt.reset()
+	}
+	if p.parseFailed {
+		p.err = nil
+		p.parseFailed = false
+	}
+}
+
+// sameLong returns true when the two long flag names select the same flag,
+// after normalization when the parser has a Normalizer.
+func (p *Parser) sameLong(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if p.normalize == nil {
+		return false
+	}
+	return p.normalize(a) == p.normalize(b)
+}
+
+// Synopsis returns a brief, POSIX style description of the command line
+// accepted by the Parser, starting with program, such as "prog [-qrv] [-l INT]
+// [--server HOST] FILE...". Arguments of options are displayed as in the usage
+// message. Boolean options with a short flag are clustered together, options
+// declared by WithRequired are not enclosed in brackets, and positional
+// arguments declared by WithPositionals follow the options. Hidden and
+// deprecated options are omitted.
+func (p *Parser) Synopsis(program string) string {
+	return p.synopsis(program, true)
+}
+
+// synopsis returns the synopsis of the Parser for program, as described by
+// Synopsis, omitting the options that are not displayed.
+func (p *Parser) synopsis(program string, interactive bool) string {
+	words := append([]string{program}, p.synopsisOptions(p.options, interactive)...)
+	return strings.Join(append(words, p.positionals...), " ")
+}
+
+// synopsisOptions returns the words that describe options in a synopsis, as
+// described by Synopsis.
+func (p *Parser) synopsisOptions(options []option, interactive bool) []string {
+	var cluster []rune
+	var words []string
+
+	for _, opt := range options {
+		if !p.displayed(opt, interactive) {
+			continue
+		}
+		required := opt.attributes().required
+		word := p.displayFlag(opt)
+
+		if name, _ := metavar(opt); name != "" {
+			word += " " + name
+		} else if short := opt.Short(); short != "" && !required {
+			r, _ := utf8.DecodeRuneInString(short)
+			cluster = append(cluster, r)
+			continue
+		}
+
+		if !required {
+			word = "[" + word + "]"
+		}
+		words = append(words, word)
+	}
+
+	if len(cluster) > 0 {
+		slices.Sort(cluster)
+		words = append([]string{"[" + string(p.displayPrefix()) + string(cluster) + "]"}, words...)
+	}
+	return words
+}
+
+// warn writes a formatted warning message to the warnings writer of the
+// parser.
+func (p *Parser) warn(format string, a ...interface{}) {
+	w := p.warnings
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "warning: "+format+"\n", a...)
+}
+
+// warnDeprecated writes a deprecation warning like warn does, unless
+// deprecation warnings have been silenced by setting the environment variable
+// GOLF_NO_DEPRECATION_WARNINGS to a non-empty value.
+func (p *Parser) warnDeprecated(format string, a ...interface{}) {
+	if os.Getenv(noDeprecationWarningsEnv) == "" {
+		p.warn(format, a...)
+	}
+}
+
+// WithDeprecated updates the Parser to mark the option selected by flag as
+// deprecated. The option continues to work, but the first time it is provided
+// during Parse, a warning that includes message, such as "use --deadline
+// instead", is written. Deprecated options are omitted from the usage message,
+// like hidden options.
+func (p *Parser) WithDeprecated(flag, message string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	if message == "" {
+		p.err = fmt.Errorf("cannot deprecate flag without message: %q", flag)
+		return p
+	}
+	f, err := p.optionToConfigure(flag)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	f.attributes().deprecated = message
+	return p
+}
+
+// WithDeprecatedAlias updates the Parser to recognize alias as a deprecated
+// flag that forwards to the option selected by target, such as after renaming
+// "--timeout" to "--deadline". The first time alias is provided during Parse,
+// a warning that recommends target is written. Deprecated aliases are omitted
+// from the usage message, like hidden options. A deprecated alias shares the
+// attributes of target, so configuring it, such as by WithMetavar, is an
+// error.
+func (p *Parser) WithDeprecatedAlias(alias, target string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	f := p.optionFromFlag(target)
+	if f == nil {
+		p.err = fmt.Errorf("cannot configure unknown flag: %q", target)
+		return p
+	}
+	if _, ok := f.(*optionAlias); ok {
+		p.err = fmt.Errorf("cannot add alias for deprecated alias: %q", target)
+		return p
+	}
+	var short, long string
+	short, long, p.err = p.parseSingleFlag(alias)
+	if p.err != nil {
+		return p
+	}
+
+	p.options = append(p.options, &optionAlias{
+		optionAttributes: optionAttributes{deprecated: fmt.Sprintf("use %q", p.displayFlag(f))},
+		long:             long,
+		short:            short,
+		target:           f,
+	})
+	return p
+}
+
+// WithDescription updates the Parser to display description in its usage
+// message, following the synopsis.
+func (p *Parser) WithDescription(description string) *Parser {
+	p.description = description
+	return p
+}
+
+// WithEnv updates the Parser to display name as the environment variable that
+// corresponds to the option selected by flag in the usage message. It only
+// describes the option: the Parser does not read the variable, which is left
+// to the program.
+func (p *Parser) WithEnv(flag, name string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	if name == "" {
+		p.err = fmt.Errorf("cannot use environment variable without name for flag: %q", flag)
+		return p
+	}
+	f, err := p.optionToConfigure(flag)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	f.attributes().env = name
+	return p
+}
+
+// WithExample updates the Parser to display command, an example invocation of
+// the program, followed by its description, in its usage message.
+func (p *Parser) WithExample(command, description string) *Parser {
+	p.examples = append(p.examples, UsageExample{Command: command, Description: description})
+	return p
+}
+
+// WithFile updates the Parser to describe path, a file used by the program,
+// such as a configuration file, in the FILES section of its man page.
+func (p *Parser) WithFile(path, description string) *Parser {
+	p.files = append(p.files, UsageFile{Path: path, Description: description})
+	return p
+}
+
+// WithGroup updates the Parser to display the options selected by flags under
+// a heading with the name of the group in the usage message, rather than with
+// the options that do not belong to a group. Groups are displayed after the
+// options that do not belong to a group, in the order they were first
+// declared. An option may belong to only one group.
+func (p *Parser) WithGroup(name string, flags ...string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	if name == "" {
+		p.err = errors.New("cannot add option group without name")
+		return p
+	}
+	for _, flag := range flags {
+		f, err := p.optionToConfigure(flag)
+		if err != nil {
+			p.err = err
+			return p
 		}
+		if group := f.attributes().group; group != "" && group != name {
+			p.err = fmt.Errorf("cannot add option to more than one group: %q: %q and %q", flag, group, name)
+			return p
+		}
+		f.attributes().group = name
+	}
+	if !slices.Contains(p.groups, name) {
+		p.groups = append(p.groups, name)
+	}
+	return p
+}
+
+// WithHelpLayout updates the Parser to display its options in usage messages
+// according to layout. The default layout is HelpLayoutStacked.
+func (p *Parser) WithHelpLayout(layout HelpLayout) *Parser {
+	p.helpLayout = layout
+	return p
+}
+
+// WithHidden updates the Parser to omit the options selected by flags from the
+// usage message, although they continue to be recognized when parsing. Hidden
+// options are displayed when revealed by WithShowHidden. They are also
+// displayed in help, but not in generated documentation such as man pages,
+// when the environment variable GOLF_SHOW_HIDDEN is not empty.
+func (p *Parser) WithHidden(flags ...string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	for _, flag := range flags {
+		f, err := p.optionToConfigure(flag)
+		if err != nil {
+			p.err = err
+			return p
+		}
+		f.attributes().hidden = true
+	}
+	return p
+}
+
+// WithMaxOccurrences updates the Parser to return an error from Parse when the
+// option identified by flag occurs more than max times on the command line.
+// When max is zero, the number of occurrences is not limited.
+func (p *Parser) WithMaxOccurrences(flag string, max int) *Parser {
+	if p.err != nil {
+		return p
+	}
+	if max < 0 {
+		p.err = fmt.Errorf("cannot use negative maximum occurrences for flag: %q: %d", flag, max)
+		return p
+	}
+	f, err := p.optionToConfigure(flag)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	f.attributes().maxOccurrences = max
+	return p
+}
+
+// WithMetavar updates the Parser to display metavar as the placeholder for
+// the argument of the option selected by flag in the usage message, rather
+// than a word enclosed in backquotes in its description, or the name of its
+// type.
+func (p *Parser) WithMetavar(flag, metavar string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	f, err := p.optionToConfigure(flag)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	f.attributes().metavar = metavar
+	return p
+}
 
-		short := opt.Short()
+// WithNormalizer updates the Parser to canonicalize long flag names with n
+// both when options are declared and when command line arguments are parsed,
+// so differently spelled names may select the same flag. Declaring two long
+// flags whose canonical forms collide is an error. Short flags are never
+// normalized.
+func (p *Parser) WithNormalizer(n Normalizer) *Parser {
+	if p.err != nil {
+		return p
+	}
+	p.normalize = n
+	if n == nil {
+		return p
+	}
+	// Options declared before the Normalizer must not collide with each
+	// other under the new canonical forms.
+	for i, opt := range p.options {
 		long := opt.Long()
+		if long == "" {
+			continue
+		}
+		if p.err = p.ensureNormalizable(long); p.err != nil {
+			return p
+		}
+		for _, other := range p.options[:i] {
+			if other.Long() != "" && p.sameLong(other.Long(), long) {
+				p.err = fmt.Errorf("cannot add option that collides with long flag: %q and %q", long, other.Long())
+				return p
+			}
+		}
+	}
+	return p
+}
 
-		if short != "" {
-			if long != "" {
-				fmt.Fprintf(w, "  -%s, --%s%s%s\n", short, long, typeName, def)
-			} else {
-				fmt.Fprintf(w, "  -%s%s%s\n", short, typeName, def)
+// WithOptionRepeatPolicy updates the Parser to apply policy to repeated
+// occurrences of the option identified by flag, overriding the policy of the
+// Parser for that option.
+func (p *Parser) WithOptionRepeatPolicy(flag string, policy RepeatPolicy) *Parser {
+	if p.err != nil {
+		return p
+	}
+	f, err := p.optionToConfigure(flag)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	attrs := f.attributes()
+	attrs.repeatPolicy = policy
+	attrs.hasRepeatPolicy = true
+	return p
+}
+
+// WithPositionals updates the Parser to display names after the options in
+// the synopsis, describing the positional arguments it accepts, such as
+// "SOURCE...", "DEST", or "[FILE]". The positional arguments are not
+// validated by Parse.
+func (p *Parser) WithPositionals(names ...string) *Parser {
+	p.positionals = append(p.positionals, names...)
+	return p
+}
+
+// WithPrefix updates the Parser to give r the meaning of kind when it is the
+// first rune of a command line argument. By default only the hyphen introduces
+// options. For instance, use PrefixNegate with the plus sign to allow "+x" to
+// turn off the boolean option "x", PrefixOption with the slash to allow "/v"
+// to select the option "v", or PrefixNone with the hyphen to stop the hyphen
+// from introducing options.
+func (p *Parser) WithPrefix(r rune, kind PrefixKind) *Parser {
+	if p.err != nil {
+		return p
+	}
+	if r == utf8.RuneError {
+		p.err = fmt.Errorf("cannot use invalid rune as a prefix: %q", r)
+		return p
+	}
+	if kind != PrefixNone {
+		// Options declared before the prefix must not be confused with it.
+		for _, opt := range p.options {
+			if short := opt.Short(); short == string(r) {
+				p.err = fmt.Errorf("cannot use flag as a prefix: %q", r)
+				return p
+			}
+			if long := opt.Long(); strings.HasPrefix(long, string(r)) {
+				p.err = fmt.Errorf("cannot use prefix that starts flag: %q: %q", r, long)
+				return p
 			}
-		} else {
-			fmt.Fprintf(w, "  --%s%s%s\n", long, typeName, def)
 		}
+	}
+	if p.prefixes == nil {
+		p.prefixes = map[rune]PrefixKind{'-': PrefixOption}
+	}
+	if kind == PrefixNone {
+		delete(p.prefixes, r)
+	} else {
+		p.prefixes[r] = kind
+	}
+	return p
+}
+
+// WithRepeatPolicy updates the Parser to apply policy to options that occur
+// more than once on the command line. The default policy is RepeatLastWins.
+func (p *Parser) WithRepeatPolicy(policy RepeatPolicy) *Parser {
+	p.repeatPolicy = policy
+	return p
+}
 
-		if description != "" {
-			fmt.Fprint(w, dw.Wrap(fmt.Sprintf("%s", description)))
+// WithRequired updates the Parser to require the options selected by flags to
+// be provided, so Parse returns an error when any of them is missing. Required
+// options are displayed without brackets in the synopsis.
+func (p *Parser) WithRequired(flags ...string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	for _, flag := range flags {
+		f, err := p.optionToConfigure(flag)
+		if err != nil {
+			p.err = err
+			return p
 		}
+		f.attributes().required = true
 	}
+	return p
+}
+
+// WithShowHidden updates the Parser to display hidden options in the usage
+// message when show is true, such as for maintainers of the program.
+func (p *Parser) WithShowHidden(show bool) *Parser {
+	p.showHidden = show
+	return p
+}
+
+// WithSingleHyphenLong updates the Parser to treat a single hyphen followed by
+// the entire name of a long flag according to mode. When the text after a
+// single hyphen exactly matches the name of a long flag, and that name is more
+// than one rune long, the long flag is selected rather than a cluster of short
+// flags. Otherwise the text is parsed as a cluster of short flags, as usual.
+func (p *Parser) WithSingleHyphenLong(mode SingleHyphenMode) *Parser {
+	p.singleHyphen = mode
+	return p
+}
+
+// WithStrictHyphen updates the Parser to return an error from Parse when an
+// argument is a lone hyphen. By default a lone hyphen, which conventionally
+// refers to standard input or standard output, is treated as an ordinary
+// argument.
+func (p *Parser) WithStrictHyphen(strict bool) *Parser {
+	p.strictHyphen = strict
+	return p
+}
+
+// WithUsageTemplate updates the Parser to render its usage message with the
+// text/template text, which is executed with a UsageData value. The usage
+// template of the Parser of a Command also renders the usage of its
+// descendants that do not have one. DefaultUsageTemplate renders the default
+// layout.
+func (p *Parser) WithUsageTemplate(text string) *Parser {
+	if p.err != nil {
+		return p
+	}
+	tmpl, err := template.New("usage").Parse(text)
+	if err != nil {
+		p.err = fmt.Errorf("cannot parse usage template: %w", err)
+		return p
+	}
+	p.usageTemplate = tmpl
+	return p
+}
+
+// WithWarnings updates the Parser to write warnings to w rather than to
+// standard error.
+func (p *Parser) WithWarnings(w io.Writer) *Parser {
+	p.warnings = w
+	return p
+}
+
+// WithWidth updates the Parser to wrap its usage message to columns, rather
+// than to the width provided by its WidthProvider.
+func (p *Parser) WithWidth(columns int) *Parser {
+	if p.err != nil {
+		return p
+	}
+	if columns < 0 {
+		p.err = fmt.Errorf("cannot use negative width: %d", columns)
+		return p
+	}
+	p.columns = columns
+	return p
+}
+
+// WithWidthProvider updates the Parser to wrap its usage message to the width
+// provided by wp, clamped between 40 and 120 columns, or 80 columns when wp
+// does not know the width. By default, the width is provided by the COLUMNS
+// environment variable, or else by the terminal of standard error or standard
+// output.
+func (p *Parser) WithWidthProvider(wp WidthProvider) *Parser {
+	p.widthProvider = wp
+	return p
 }
@@ -1,6 +1,10 @@
 package golf
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
 func ensureParserError(t *testing.T, description string, callback func(t *testing.T, p *Parser)) {
 	t.Helper()
@@ -448,6 +452,847 @@ func TestParseArgs(t *testing.T) {
 	})
 }
 
+func TestParseSingleHyphenLong(t *testing.T) {
+	t.Run("short only rejects long name", func(t *testing.T) {
+		var i int
+		var p Parser
+		p.WithIntVarP(&i, 'l', "limit", "limit results")
+
+		ensureError(t, p.Parse([]string{"-limit", "4"}), `parsing "imit"`)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		var b bool
+		var i int
+		var p Parser
+		p.
+			WithBoolVarP(&b, 'v', "verbose", "print verbose info").
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithSingleHyphenLong(SingleHyphenLongAllowed)
+
+		ensureError(t, p.Parse([]string{"-limit", "4", "-verbose", "some"}))
+
+		if got, want := b, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := i, 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureStringSlicesMatch(t, p.Args(), []string{"some"})
+		if got, want := p.NFlag(), 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("short cluster when not a long name", func(t *testing.T) {
+		var a, b bool
+		var p Parser
+		p.
+			WithBoolVar(&a, "a", "").
+			WithBoolVar(&b, "b", "").
+			WithSingleHyphenLong(SingleHyphenLongAllowed)
+
+		ensureError(t, p.Parse([]string{"-ab"}))
+
+		if got, want := a, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := b, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("long name takes precedence over short cluster", func(t *testing.T) {
+		var a, b, ab bool
+		var p Parser
+		p.
+			WithBoolVar(&a, "a", "").
+			WithBoolVar(&b, "b", "").
+			WithBoolVar(&ab, "ab", "").
+			WithSingleHyphenLong(SingleHyphenLongAllowed)
+
+		ensureError(t, p.Parse([]string{"-ab"}))
+
+		if got, want := a, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := b, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := ab, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("deprecated", func(t *testing.T) {
+		var i int
+		var p Parser
+		var warnings strings.Builder
+		p.
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithSingleHyphenLong(SingleHyphenLongDeprecated).
+			WithWarnings(&warnings)
+
+		ensureError(t, p.Parse([]string{"-limit", "4"}))
+
+		if got, want := i, 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := warnings.String(), "warning: single-hyphen long flag is deprecated: \"-limit\"; use \"--limit\"\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestParseRepeatPolicy(t *testing.T) {
+	t.Run("last wins by default", func(t *testing.T) {
+		var i int
+		var p Parser
+		p.WithIntVarP(&i, 'l', "limit", "limit results")
+
+		ensureError(t, p.Parse([]string{"--limit", "3", "-l5"}))
+
+		if got, want := i, 5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("first wins", func(t *testing.T) {
+		var i int
+		var p Parser
+		p.
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithRepeatPolicy(RepeatFirstWins)
+
+		ensureError(t, p.Parse([]string{"--limit", "3", "-l5", "some"}))
+
+		if got, want := i, 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureStringSlicesMatch(t, p.Args(), []string{"some"})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var i int
+		var p Parser
+		p.
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithRepeatPolicy(RepeatError)
+
+		ensureError(t, p.Parse([]string{"--limit", "3", "some", "-l5"}), `option repeated: "--limit" at argument 1 and "-l" at argument 4`)
+	})
+
+	t.Run("option overrides parser", func(t *testing.T) {
+		var b bool
+		var i int
+		var p Parser
+		p.
+			WithBoolVarP(&b, 'v', "verbose", "print verbose info").
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithRepeatPolicy(RepeatError).
+			WithOptionRepeatPolicy("v", RepeatLastWins)
+
+		ensureError(t, p.Parse([]string{"-vv", "--limit", "3"}))
+
+		if got, want := b, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		var p Parser
+		p.WithOptionRepeatPolicy("limit", RepeatError)
+		ensureError(t, p.Err(), `cannot configure unknown flag: "limit"`)
+	})
+}
+
+func TestParseMaxOccurrences(t *testing.T) {
+	var b bool
+	var p Parser
+	p.
+		WithBoolVarP(&b, 'v', "verbose", "print verbose info").
+		WithMaxOccurrences("verbose", 2)
+
+	t.Run("within maximum", func(t *testing.T) {
+		ensureError(t, p.Parse([]string{"-v", "--verbose"}))
+	})
+
+	t.Run("exceeds maximum", func(t *testing.T) {
+		ensureError(t, p.Parse([]string{"-vv", "--verbose"}), `option allows at most 2 occurrences: "-v" at argument 1 and "--verbose" at argument 2`)
+	})
+}
+
+func TestParseLoneHyphen(t *testing.T) {
+	t.Run("argument", func(t *testing.T) {
+		var b bool
+		var p Parser
+		p.WithBoolVarP(&b, 'v', "verbose", "print verbose info")
+
+		ensureError(t, p.Parse([]string{"-v", "-", "some"}))
+
+		ensureStringSlicesMatch(t, p.Args(), []string{"-", "some"})
+
+		if got, want := p.NArg(), 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := p.NFlag(), 1; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("option value", func(t *testing.T) {
+		var s string
+		var p Parser
+		p.WithStringVarP(&s, 'o', "output", "write output to file")
+
+		ensureError(t, p.Parse([]string{"-o", "-"}))
+		if got, want := s, "-"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		ensureError(t, p.Parse([]string{"--output", "-"}))
+		if got, want := s, "-"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		var p Parser
+		p.WithStrictHyphen(true)
+
+		ensureError(t, p.Parse([]string{"some", "-"}), "hyphen without flags")
+		ensureStringSlicesMatch(t, p.Args(), []string{"some", "-"})
+	})
+}
+
+func TestParseNormalizer(t *testing.T) {
+	t.Run("without normalizer", func(t *testing.T) {
+		var b bool
+		var p Parser
+		p.WithBoolVar(&b, "dry-run", "do not make changes")
+
+		ensureError(t, p.Parse([]string{"--dry_run"}), `unknown flag: "dry_run"`)
+	})
+
+	t.Run("separators", func(t *testing.T) {
+		var b bool
+		var p Parser
+		p.
+			WithNormalizer(NormalizeSeparators).
+			WithBoolVar(&b, "dry-run", "do not make changes")
+
+		ensureError(t, p.Parse([]string{"--dry_run"}))
+		if got, want := b, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureError(t, p.Parse([]string{"--Dry-Run"}), `unknown flag: "Dry-Run"`)
+	})
+
+	t.Run("case", func(t *testing.T) {
+		var b bool
+		var p Parser
+		p.
+			WithNormalizer(NormalizeCase).
+			WithBoolVar(&b, "dry-run", "do not make changes")
+
+		ensureError(t, p.Parse([]string{"--DRY-RUN"}))
+		if got, want := b, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("loose", func(t *testing.T) {
+		var p Parser
+		p.WithNormalizer(NormalizeLoose)
+		b := p.WithBool("dry-run", false, "do not make changes")
+
+		for _, arg := range []string{"--dry-run", "--dry_run", "--DryRun", "--dryrun"} {
+			*b = false
+			ensureError(t, p.Parse([]string{arg}))
+			if got, want := *b, true; got != want {
+				t.Errorf("%s: GOT: %v; WANT: %v", arg, got, want)
+			}
+		}
+	})
+
+	t.Run("short flags not normalized", func(t *testing.T) {
+		var v, V bool
+		var p Parser
+		p.
+			WithNormalizer(NormalizeCase).
+			WithBoolVar(&v, "v", "print verbose info").
+			WithBoolVar(&V, "V", "print version info")
+
+		ensureError(t, p.Parse([]string{"-V"}))
+		if got, want := v, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := V, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestNormalizerCollisions(t *testing.T) {
+	var a, b bool
+
+	ensureParserError(t, "cannot add option that collides with long flag: \"dry_run\" and \"dry-run\"", func(t *testing.T, p *Parser) {
+		p.
+			WithNormalizer(NormalizeSeparators).
+			WithBoolVar(&a, "dry-run", "").
+			WithBoolVar(&b, "dry_run", "")
+	})
+	ensureParserError(t, "cannot add option that collides with long flag: \"DryRun\" and \"dry-run\"", func(t *testing.T, p *Parser) {
+		p.
+			WithNormalizer(NormalizeLoose).
+			WithBoolVarP(&a, 'd', "dry-run", "").
+			WithBoolVarP(&b, 'D', "DryRun", "")
+	})
+	ensureParserError(t, "cannot add option that collides with long flag: \"DRY-RUN\" and \"dry-run\"", func(t *testing.T, p *Parser) {
+		p.
+			WithBoolVar(&a, "dry-run", "").
+			WithBoolVar(&b, "DRY-RUN", "").
+			WithNormalizer(NormalizeCase)
+	})
+	ensureParserError(t, "cannot use flag that normalizes to empty string: \"__\"", func(t *testing.T, p *Parser) {
+		p.
+			WithNormalizer(NormalizeLoose).
+			WithBoolVar(&a, "__", "")
+	})
+}
+
+func TestParsePrefix(t *testing.T) {
+	t.Run("negate", func(t *testing.T) {
+		var x, v bool
+		var i int
+		var p Parser
+		p.
+			WithBoolVar(&x, "x", "trace commands").
+			WithBoolVarP(&v, 'v', "verbose", "print verbose info").
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithPrefix('+', PrefixNegate)
+
+		ensureError(t, p.Parse([]string{"-xv", "+x", "-l", "4", "++verbose", "some"}))
+
+		if got, want := x, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := v, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := i, 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureStringSlicesMatch(t, p.Args(), []string{"some"})
+	})
+
+	t.Run("negate not boolean", func(t *testing.T) {
+		var i int
+		var p Parser
+		p.
+			WithIntVarP(&i, 'l', "limit", "limit results").
+			WithPrefix('+', PrefixNegate)
+
+		ensureError(t, p.Parse([]string{"+l"}), "cannot negate flag that is not boolean: 'l'")
+	})
+
+	t.Run("lone and doubled negate are arguments", func(t *testing.T) {
+		var p Parser
+		p.WithPrefix('+', PrefixNegate)
+
+		ensureError(t, p.Parse([]string{"+", "++"}))
+		ensureStringSlicesMatch(t, p.Args(), []string{"+", "++"})
+	})
+
+	t.Run("slash instead of hyphen", func(t *testing.T) {
+		var v bool
+		var s string
+		var p Parser
+		p.
+			WithBoolVarP(&v, 'v', "verbose", "print verbose info").
+			WithStringVarP(&s, 's', "server", "ask server").
+			WithPrefix('/', PrefixOption).
+			WithPrefix('-', PrefixNone)
+
+		ensureError(t, p.Parse([]string{"/v", "//server", "host1", "//", "-v", "/s"}))
+
+		if got, want := v, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := s, "host1"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureStringSlicesMatch(t, p.Args(), []string{"-v", "/s"})
+
+		var buf strings.Builder
+		p.PrintDefaultsTo(&buf)
+		if got, want := buf.String(), "  /v, //verbose\n    print verbose info\n  /s, //server STRING (default: \"\")\n    ask server\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("flag conflicts with prefix", func(t *testing.T) {
+		var a bool
+
+		ensureParserError(t, "cannot use prefix as a flag: '+'", func(t *testing.T, p *Parser) {
+			p.
+				WithPrefix('+', PrefixNegate).
+				WithBoolVarP(&a, '+', "plus", "")
+		})
+		ensureParserError(t, "cannot use flag that starts with a prefix: \"/root\"", func(t *testing.T, p *Parser) {
+			p.
+				WithPrefix('/', PrefixOption).
+				WithBoolVar(&a, "/root", "")
+		})
+		ensureParserError(t, "cannot use flag as a prefix: '+'", func(t *testing.T, p *Parser) {
+			p.
+				WithBoolVar(&a, "+", "").
+				WithPrefix('+', PrefixNegate)
+		})
+	})
+}
+
+func TestParseHelp(t *testing.T) {
+	t.Run("requested", func(t *testing.T) {
+		for _, args := range [][]string{{"-h"}, {"--help"}, {"-vh"}, {"some", "--help"}} {
+			var b bool
+			var p Parser
+			p.WithBoolVarP(&b, 'v', "verbose", "print verbose info")
+
+			if got, want := p.Parse(args), ErrHelp; got != want {
+				t.Errorf("%q: GOT: %v; WANT: %v", args, got, want)
+			}
+		}
+	})
+
+	t.Run("normalized", func(t *testing.T) {
+		var p Parser
+		p.WithNormalizer(NormalizeCase)
+
+		if got, want := p.Parse([]string{"--HELP"}), ErrHelp; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("declared by program", func(t *testing.T) {
+		var h bool
+		var s string
+		var p Parser
+		p.
+			WithBoolVar(&h, "help", "show help").
+			WithStringVar(&s, "h", "host name")
+
+		ensureError(t, p.Parse([]string{"--help", "-h", "example.com"}))
+		if got, want := h, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := s, "example.com"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestParserReset(t *testing.T) {
+	t.Run("restores defaults and clears parse error", func(t *testing.T) {
+		var p Parser
+		a := p.WithBool("a", false, "some bool")
+		s := p.WithString("s", "default", "some string")
+
+		ensureError(t, p.Parse([]string{"-a", "-s", "other", "-x"}), `unknown flag: 'x'`)
+		p.reset()
+
+		ensureError(t, p.Err())
+		if got, want := *a, false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := *s, "default"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		ensureError(t, p.Parse([]string{"-a"}))
+	})
+
+	t.Run("keeps definition error", func(t *testing.T) {
+		var p Parser
+		p.WithBool("a", false, "some bool")
+		p.WithMaxOccurrences("z", 1)
+		want := p.Err()
+		if want == nil {
+			t.Fatal("GOT: nil; WANT: error")
+		}
+		p.reset()
+		if got := p.Err(); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestParserWithGroup(t *testing.T) {
+	t.Run("print defaults", func(t *testing.T) {
+		var p Parser
+		p.WithBoolP('v', "verbose", false, "print verbose info")
+		p.WithInt("port", 80, "port to listen on")
+		p.WithString("log-level", "info", "minimum level of logged events")
+		p.WithString("host", "localhost", "host to listen on")
+		p.WithBool("trace", false, "trace everything")
+		p.WithGroup("Networking", "host", "port")
+		p.WithGroup("Logging", "log-level")
+		p.WithGroup("Networking", "trace")
+		ensureError(t, p.Err())
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+
+		want := `  -v, --verbose
+    print verbose info
+
+Networking:
+  --port INT (default: 80)
+    port to listen on
+  --host STRING (default: "localhost")
+    host to listen on
+  --trace
+    trace everything
+
+Logging:
+  --log-level STRING (default: "info")
+    minimum level of logged events
+`
+		if got := output.String(); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("only groups", func(t *testing.T) {
+		var p Parser
+		p.WithBool("trace", false, "trace everything")
+		p.WithGroup("Debugging", "trace")
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+
+		if got, want := output.String(), "Debugging:\n  --trace\n    trace everything\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	ensureParserError(t, "cannot add option group without name", func(t *testing.T, p *Parser) {
+		p.WithBool("trace", false, "trace everything")
+		p.WithGroup("", "trace")
+	})
+
+	ensureParserError(t, `cannot configure unknown flag: "trace"`, func(t *testing.T, p *Parser) {
+		p.WithGroup("Debugging", "trace")
+	})
+
+	ensureParserError(t, `cannot add option to more than one group: "trace": "Debugging" and "Logging"`, func(t *testing.T, p *Parser) {
+		p.WithBool("trace", false, "trace everything")
+		p.WithGroup("Debugging", "trace")
+		p.WithGroup("Logging", "trace")
+	})
+}
+
+func TestParserMetavar(t *testing.T) {
+	var p Parser
+	p.WithStringP('s', "server", "", "connect to `HOST` on startup")
+	p.WithInt("limit", 10, "maximum number of `results`, or `0` for none")
+	p.WithDuration("timeout", 0, "how long to wait")
+	p.WithBool("force", false, "skip the `safety` checks")
+	p.WithUint("retries", 3, "how many times to retry")
+	p.WithMetavar("retries", "COUNT")
+	p.WithString("user", "", "log in as `NAME`")
+	p.WithMetavar("user", "LOGIN")
+	ensureError(t, p.Err())
+
+	var output strings.Builder
+	p.PrintDefaultsTo(&output)
+
+	want := `  -s, --server HOST (default: "")
+    connect to HOST on startup
+  --limit results (default: 10)
+    maximum number of results, or ` + "`0`" + ` for none
+  --timeout DURATION (default: 0s)
+    how long to wait
+  --force
+    skip the safety checks
+  --retries COUNT (default: 3)
+    how many times to retry
+  --user LOGIN (default: "")
+    log in as NAME
+`
+	if got := output.String(); got != want {
+		t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+	}
+
+	ensureParserError(t, `cannot configure unknown flag: "server"`, func(t *testing.T, p *Parser) {
+		p.WithMetavar("server", "HOST")
+	})
+}
+
+func TestParserWithHidden(t *testing.T) {
+	const visible = "  -v\n    print verbose info\n"
+	const all = visible + "  --tune INT (default: 0)\n    internal tuning knob\n  --debug-internals\n    dump internal state\n"
+
+	for _, tc := range []struct {
+		name       string
+		showHidden bool
+		env        string // value of the environment variable that reveals hidden options
+		help       string
+		synopsis   string // synopsis in the documentation
+	}{
+		{name: "omitted", help: visible, synopsis: "prog [-v]"},
+		{name: "revealed by parser", showHidden: true, help: all, synopsis: "prog [-v] [--tune INT] [--debug-internals]"},
+		{name: "revealed by environment in help only", env: "1", help: all, synopsis: "prog [-v]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(showHiddenEnv, tc.env)
+
+			var p Parser
+			p.WithBool("v", false, "print verbose info")
+			tune := p.WithInt("tune", 0, "internal tuning knob")
+			debug := p.WithBool("debug-internals", false, "dump internal state")
+			p.WithHidden("tune", "debug-internals")
+			p.WithShowHidden(tc.showHidden)
+			ensureError(t, p.Err())
+
+			ensureError(t, p.Parse([]string{"--tune", "4", "--debug-internals"}))
+			if *tune != 4 || !*debug {
+				t.Errorf("GOT: %v, %v; WANT: 4, true", *tune, *debug)
+			}
+
+			var output strings.Builder
+			p.PrintDefaultsTo(&output)
+			if got, want := output.String(), tc.help; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+
+			if got, want := p.UsageData("prog").Synopsis, tc.synopsis; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+
+			output.Reset()
+			ensureError(t, Markdown{}.WriteParser(&output, &p, "prog"))
+			if got := output.String(); strings.Contains(got, "tune") != tc.showHidden {
+				t.Errorf("GOT:\n%s\nWANT: hidden option documented: %t", got, tc.showHidden)
+			}
+		})
+	}
+
+	ensureParserError(t, `cannot configure unknown flag: "tune"`, func(t *testing.T, p *Parser) {
+		p.WithHidden("tune")
+	})
+}
+
+func TestParserDeprecated(t *testing.T) {
+	t.Run("deprecated option", func(t *testing.T) {
+		var warnings strings.Builder
+		var p Parser
+		p.WithWarnings(&warnings)
+		legacy := p.WithBoolP('l', "legacy", false, "use legacy mode")
+		p.WithDeprecated("legacy", "legacy mode will be removed")
+		ensureError(t, p.Err())
+
+		ensureError(t, p.Parse([]string{"--legacy", "-l"}))
+		if got, want := *legacy, true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := warnings.String(), "warning: flag is deprecated: \"--legacy\"; legacy mode will be removed\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("deprecated alias", func(t *testing.T) {
+		var warnings strings.Builder
+		var p Parser
+		p.WithWarnings(&warnings)
+		deadline := p.WithDuration("deadline", 0, "how long to wait")
+		p.WithDeprecatedAlias("timeout", "deadline")
+		p.WithDeprecatedAlias("t", "deadline")
+		ensureError(t, p.Err())
+
+		ensureError(t, p.Parse([]string{"--timeout", "5s", "-t", "6s", "--timeout", "7s"}))
+		if got, want := *deadline, 7*time.Second; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		want := "warning: flag is deprecated: \"--timeout\"; use \"--deadline\"\n" +
+			"warning: flag is deprecated: \"-t\"; use \"--deadline\"\n"
+		if got := warnings.String(); got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		warnings.Reset()
+		ensureError(t, p.Parse([]string{"--timeout", "5s"}))
+		if got, want := warnings.String(), "warning: flag is deprecated: \"--timeout\"; use \"--deadline\"\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("alias shares occurrences with target", func(t *testing.T) {
+		var p Parser
+		p.WithWarnings(new(strings.Builder))
+		p.WithDuration("deadline", 0, "how long to wait")
+		p.WithDeprecatedAlias("timeout", "deadline")
+		p.WithMaxOccurrences("deadline", 1)
+
+		ensureError(t, p.Parse([]string{"--deadline", "5s", "--timeout", "6s"}), `option allows only one occurrence: "--deadline" at argument 1 and "--timeout" at argument 3`)
+	})
+
+	t.Run("silenced", func(t *testing.T) {
+		t.Setenv(noDeprecationWarningsEnv, "1")
+		var warnings strings.Builder
+		var p Parser
+		p.WithWarnings(&warnings)
+		p.WithDuration("deadline", 0, "how long to wait")
+		p.WithDeprecatedAlias("timeout", "deadline")
+
+		ensureError(t, p.Parse([]string{"--timeout", "5s"}))
+		if got, want := warnings.String(), ""; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("omitted from usage", func(t *testing.T) {
+		var p Parser
+		p.WithDuration("deadline", 0, "how long to wait")
+		p.WithDeprecatedAlias("timeout", "deadline")
+		p.WithBool("legacy", false, "use legacy mode")
+		p.WithDeprecated("legacy", "legacy mode will be removed")
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+		if got, want := output.String(), "  --deadline DURATION (default: 0s)\n    how long to wait\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	ensureParserError(t, `cannot configure unknown flag: "deadline"`, func(t *testing.T, p *Parser) {
+		p.WithDeprecatedAlias("timeout", "deadline")
+	})
+
+	ensureParserError(t, `cannot add option that duplicates long flag: "deadline"`, func(t *testing.T, p *Parser) {
+		p.WithDuration("deadline", 0, "how long to wait")
+		p.WithDeprecatedAlias("deadline", "deadline")
+	})
+
+	ensureParserError(t, `cannot add alias for deprecated alias: "timeout"`, func(t *testing.T, p *Parser) {
+		p.WithDuration("deadline", 0, "how long to wait")
+		p.WithDeprecatedAlias("timeout", "deadline")
+		p.WithDeprecatedAlias("wait", "timeout")
+	})
+
+	t.Run("attributes of deprecated alias", func(t *testing.T) {
+		for name, configure := range map[string]func(p *Parser){
+			"WithDeprecated":         func(p *Parser) { p.WithDeprecated("timeout", "going away") },
+			"WithEnv":                func(p *Parser) { p.WithEnv("timeout", "TIMEOUT") },
+			"WithGroup":              func(p *Parser) { p.WithGroup("Timing", "timeout") },
+			"WithHidden":             func(p *Parser) { p.WithHidden("timeout") },
+			"WithMaxOccurrences":     func(p *Parser) { p.WithMaxOccurrences("timeout", 1) },
+			"WithMetavar":            func(p *Parser) { p.WithMetavar("timeout", "DURATION") },
+			"WithOptionRepeatPolicy": func(p *Parser) { p.WithOptionRepeatPolicy("timeout", RepeatError) },
+			"WithRequired":           func(p *Parser) { p.WithRequired("timeout") },
+		} {
+			t.Run(name, func(t *testing.T) {
+				var p Parser
+				p.WithDuration("deadline", 0, "how long to wait")
+				p.WithDeprecatedAlias("timeout", "deadline")
+				configure(&p)
+				ensureError(t, p.Err(), `cannot configure deprecated alias: "timeout"`)
+			})
+		}
+	})
+
+	ensureParserError(t, `cannot deprecate flag without message: "legacy"`, func(t *testing.T, p *Parser) {
+		p.WithBool("legacy", false, "use legacy mode")
+		p.WithDeprecated("legacy", "")
+	})
+}
+
+func TestParserSynopsis(t *testing.T) {
+	t.Run("options and positionals", func(t *testing.T) {
+		var p Parser
+		p.WithBoolP('v', "verbose", false, "print verbose info")
+		p.WithBool("q", false, "be quiet")
+		p.WithInt("l", 0, "limit output")
+		p.WithBool("r", false, "recurse")
+		p.WithString("server", "", "connect to `HOST`")
+		p.WithBool("force", false, "skip safety checks")
+		p.WithDuration("t", 0, "time out")
+		p.WithString("internal", "", "internal knob")
+		p.WithHidden("internal")
+		p.WithPositionals("FILE...")
+		ensureError(t, p.Err())
+
+		if got, want := p.Synopsis("prog"), "prog [-qrv] [-l INT] [--server HOST] [--force] [-t DURATION] FILE..."; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		var p Parser
+		p.WithBool("a", false, "some bool")
+		p.WithBool("b", false, "required bool")
+		p.WithStringP('s', "server", "", "connect to `HOST`")
+		p.WithRequired("b", "server")
+		p.WithPositionals("SOURCE", "[DEST]")
+		ensureError(t, p.Err())
+
+		if got, want := p.Synopsis("prog"), "prog [-a] -b -s HOST SOURCE [DEST]"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var p Parser
+		if got, want := p.Synopsis("prog"), "prog"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
+
+func TestParserWithRequired(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		err  string
+		rest []string
+	}{
+		{name: "provided", args: []string{"-v", "--server", "example.com", "arg"}, rest: []string{"arg"}},
+		{name: "missing", args: []string{"-v", "arg"}, err: `missing required flag: "-s"`},
+		{name: "missing after double hyphen", args: []string{"--", "--server", "example.com"}, err: `missing required flag: "-s"`},
+		{name: "help", args: []string{"-h"}, err: ErrHelp.Error()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var p Parser
+			p.WithBool("v", false, "print verbose info")
+			p.WithStringP('s', "server", "", "connect to `HOST`")
+			p.WithRequired("server")
+			ensureError(t, p.Err())
+
+			ensureError(t, p.Parse(tc.args), tc.err)
+			if tc.err == "" {
+				ensureStringSlicesMatch(t, p.Args(), tc.rest)
+			}
+		})
+	}
+
+	t.Run("print defaults", func(t *testing.T) {
+		var p Parser
+		p.WithBool("v", false, "print verbose info")
+		p.WithStringP('s', "server", "", "connect to `HOST`")
+		p.WithRequired("server")
+		ensureError(t, p.Err())
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+		if got, want := output.String(), "  -v\n    print verbose info\n  -s, --server HOST (required)\n    connect to HOST\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	ensureParserError(t, `cannot configure unknown flag: "server"`, func(t *testing.T, p *Parser) {
+		p.WithRequired("server")
+	})
+}
+
 func BenchmarkBool(b *testing.B) {
 	var u bool
 	d := "\n"
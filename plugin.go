@@ -0,0 +1,168 @@
+package golf
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pluginWaitDelay is how long a plugin may take to exit after it is
+// interrupted because the context of its command is done, before it is killed.
+const pluginWaitDelay = 5 * time.Second
+
+// PluginFinder discovers external executables that act as commands, or
+// plugins, such as "prog-foo" acting as the "foo" command of "prog".
+type PluginFinder interface {
+	// Find returns the path of the executable with the specified name, and
+	// true, or false when there is no such executable.
+	Find(name string) (string, bool)
+
+	// List returns the sorted names of the executables whose names start
+	// with prefix.
+	List(prefix string) []string
+}
+
+// PathPlugins returns a PluginFinder that searches for executables in the
+// directories of path, which is a list of directories separated by
+// os.PathListSeparator, such as the value of the PATH environment variable.
+// When more than one directory has an executable with the same name, the
+// first one found is used.
+func PathPlugins(path string) PluginFinder {
+	var dirs pathPlugins
+	for _, dir := range filepath.SplitList(path) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// pathPlugins is a PluginFinder that searches a list of directories.
+type pathPlugins []string
+
+func (dirs pathPlugins) Find(name string) (string, bool) {
+	for _, dir := range dirs {
+		pathname := filepath.Join(dir, name)
+		if isExecutable(pathname) {
+			return pathname, true
+		}
+	}
+	return "", false
+}
+
+func (dirs pathPlugins) List(prefix string) []string {
+	seen := make(map[string]struct{})
+	var names []string
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // directories in a search path need not exist
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			if isExecutable(filepath.Join(dir, name)) {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// isExecutable returns true when pathname is a regular file, after following
+// symbolic links, with at least one execute permission bit set.
+func isExecutable(pathname string) bool {
+	fi, err := os.Stat(pathname)
+	if err != nil {
+		return false
+	}
+	return fi.Mode().IsRegular() && fi.Mode().Perm()&0111 != 0
+}
+
+// plugins returns the PluginFinder of c or of its nearest ancestor that has
+// one, or nil when plugins are not enabled.
+func (c *Command) plugins() PluginFinder {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.Plugins != nil {
+			return cmd.Plugins
+		}
+	}
+	return nil
+}
+
+// pluginPrefix returns the prefix of the names of executables that act as
+// child commands of c, which is its path with spaces replaced by hyphens,
+// followed by a hyphen.
+func (c *Command) pluginPrefix() string {
+	return strings.ReplaceAll(c.Path(), " ", "-") + "-"
+}
+
+// pluginNames returns the names of the plugins that act as child commands of
+// c, excluding those hidden by built-in child commands, and those that act as
+// descendants of built-in child commands.
+func (c *Command) pluginNames() []string {
+	finder := c.plugins()
+	if finder == nil {
+		return nil
+	}
+	prefix := c.pluginPrefix()
+
+	var names []string
+	for _, executable := range finder.List(prefix) {
+		name := strings.TrimPrefix(executable, prefix)
+		if name == "" || name == helpCommandName || c.command(name) != nil {
+			continue
+		}
+		if i := strings.IndexByte(name, '-'); i > 0 && c.command(name[:i]) != nil {
+			continue // plugin acts as child command of a built-in child command
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// runPlugin runs the executable that acts as the child command of c with the
+// specified name, with args as its arguments, and returns true. It returns
+// false when there is no such executable. The executable inherits the
+// environment, standard input, standard output, and standard error of the
+// program. When the executable exits with a non-zero status code, the error
+// is an *exec.ExitError, whose ExitCode method returns the status code. When
+// ctx is done, the executable is interrupted, and killed when it has not
+// exited after pluginWaitDelay.
+func (c *Command) runPlugin(ctx context.Context, name string, args []string) (bool, error) {
+	finder := c.plugins()
+	if finder == nil || name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return false, nil
+	}
+	pathname, ok := finder.Find(c.pluginPrefix() + name)
+	if !ok {
+		return false, nil
+	}
+
+	cmd := exec.CommandContext(ctx, pathname, args...)
+	cmd.Cancel = func() error {
+		// Let the plugin shut down gracefully, as the program itself may.
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill() // interrupt not supported on Windows
+		}
+		return nil
+	}
+	cmd.WaitDelay = pluginWaitDelay
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return true, cmd.Run()
+}
@@ -0,0 +1,150 @@
+package golf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePlugin writes an executable shell script to dir that records its
+// arguments to the file named by the GOLF_TEST_PLUGIN_OUTPUT environment
+// variable, then exits with status code.
+func writePlugin(t *testing.T, dir, name, code string) {
+	t.Helper()
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"$GOLF_TEST_PLUGIN_OUTPUT\"\nexit " + code + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test scripts require a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	outputPathname := filepath.Join(dir, "output")
+	t.Setenv("GOLF_TEST_PLUGIN_OUTPUT", outputPathname)
+
+	writePlugin(t, dir, "prog-qux", "0")
+	writePlugin(t, dir, "prog-fail", "3")
+	writePlugin(t, dir, "prog-bar-quux", "0")
+	writePlugin(t, dir, "prog-foo", "0") // hidden by built-in command
+	if err := os.WriteFile(filepath.Join(dir, "prog-data"), nil, 0644); err != nil {
+		t.Fatal(err) // not executable, so not a plugin
+	}
+	plugins := PathPlugins(dir + string(os.PathListSeparator) + filepath.Join(dir, "missing"))
+
+	readOutput := func() string {
+		t.Helper()
+		buf, err := os.ReadFile(outputPathname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Remove(outputPathname); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	t.Run("arguments passed verbatim", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Plugins = plugins
+		ensureError(t, root.Execute([]string{"-v", "qux", "-x", "--long", "some", "--"}))
+		if got, want := readOutput(), "-x\n--long\nsome\n--\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Plugins = plugins
+		ensureError(t, root.Execute([]string{"bar", "quux", "some"}))
+		if got, want := readOutput(), "some\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Plugins = plugins
+		err := root.Execute([]string{"fail"})
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) {
+			t.Fatalf("GOT: %v; WANT: %T", err, ee)
+		}
+		if got, want := ee.ExitCode(), 3; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		_ = readOutput()
+	})
+
+	t.Run("help", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Plugins = plugins
+		ensureError(t, root.Execute([]string{"help", "qux"}))
+		if got, want := readOutput(), "--help\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("listed in usage", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Plugins = plugins
+
+		var output strings.Builder
+		root.PrintUsageTo(&output)
+		if got, want := output.String(), "\nPlugins:\n  fail\n  qux\n"; !strings.Contains(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+
+		output.Reset()
+		root.Commands()[1].PrintUsageTo(&output)
+		if got, want := output.String(), "\nPlugins:\n  quux\n"; !strings.Contains(got, want) {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		script := "#!/bin/sh\nexec sleep 10\n"
+		if err := os.WriteFile(filepath.Join(dir, "prog-sleep"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(filepath.Join(dir, "prog-sleep"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		root, _ := newTestTree(t)
+		root.Plugins = plugins
+		start := time.Now()
+		var ee *exec.ExitError
+		if err := root.ExecuteContext(ctx, []string{"sleep"}); !errors.As(err, &ee) {
+			t.Fatalf("GOT: %v; WANT: %T", err, ee)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("GOT: %v; WANT: plugin interrupted", elapsed)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Output = new(strings.Builder)
+		root.Plugins = plugins
+		ensureError(t, root.Execute([]string{"data"}), `unknown command: "data"`)
+	})
+
+	t.Run("without children", func(t *testing.T) {
+		root := &Command{Name: "prog", Output: new(strings.Builder), Plugins: PathPlugins(dir)}
+		ensureError(t, root.Execute([]string{"qux", "some"}))
+		if got, want := readOutput(), "some\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package golf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Exit status codes used by ExitCode.
+const (
+	exitSuccess = 0 // command succeeded, or help was requested
+	exitFailure = 1 // command failed
+	exitUsage   = 2 // command line arguments were invalid
+)
+
+// ExitError is an error that a Run or RunContext function may return to
+// request a particular exit status code from ExitCode.
+type ExitError struct {
+	Code int   // exit status code
+	Err  error // optional underlying error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) ExitCode() int { return e.Code }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the exit status code a program ought to use after Execute
+// or ExecuteContext returns err. It returns 0 when err is nil or ErrHelp, 2
+// when err is a *UsageError, the code of the error when it has an ExitCode
+// method, such as *ExitError, or the *exec.ExitError from running a plugin,
+// and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil || errors.Is(err, ErrHelp) {
+		return exitSuccess
+	}
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		return exitUsage
+	}
+	var ec interface{ ExitCode() int }
+	if errors.As(err, &ec) {
+		if code := ec.ExitCode(); code > 0 {
+			return code
+		}
+	}
+	return exitFailure
+}
+
+// signalExitCode returns the conventional exit status code of a program
+// terminated by sig, which is 128 plus the number of the signal.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return exitFailure
+}
+
+// signaledExitCode returns the exit status code of a command that returned
+// err after the program received sig, which is 0 when the command shut down
+// gracefully, and the conventional exit status code for sig otherwise.
+func signaledExitCode(err error, sig os.Signal) int {
+	if err == nil {
+		return exitSuccess
+	}
+	return signalExitCode(sig)
+}
+
+// Runner runs a command tree as the main function of a program, cancelling
+// the context provided to the RunContext function of the selected command
+// when the program receives a signal.
+type Runner struct {
+	// Signals are the signals that cancel the context. When empty,
+	// os.Interrupt and syscall.SIGTERM are used.
+	Signals []os.Signal
+
+	// GracePeriod is how long to wait, after the first signal, for the
+	// selected command to return before exiting anyway. When zero, there is
+	// no limit, but a second signal always causes an immediate exit.
+	GracePeriod time.Duration
+
+	// Exit is invoked with the exit status code when the program must exit
+	// before the selected command returns. When nil, os.Exit is used.
+	Exit func(code int)
+}
+
+// Run invokes ExecuteContext on c with args, using a context that is cancelled
+// when the program receives one of the signals of r, and returns the exit
+// status code from ExitCode. When the command returns an error after the first
+// signal, Run returns the conventional exit status code for that signal, 128
+// plus its number. When a second signal is received, or the grace period
+// elapses after the first signal, before the command returns, Run invokes the
+// Exit function of r without waiting for the command to return.
+func (r *Runner) Run(c *Command, args []string) int {
+	return r.run(func(ctx context.Context) error { return c.ExecuteContext(ctx, args) })
+}
+
+// RunMultiCall is like Run, but invokes ExecuteMultiCallContext on c with
+// argv, which is the complete command line including the name of the program,
+// such as os.Args.
+func (r *Runner) RunMultiCall(c *Command, argv []string) int {
+	return r.run(func(ctx context.Context) error { return c.ExecuteMultiCallContext(ctx, argv) })
+}
+
+// run invokes execute with a context that is cancelled when the program
+// receives one of the signals of r, and returns the exit status code as
+// described by Run.
+func (r *Runner) run(execute func(ctx context.Context) error) int {
+	signals := r.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	// NOTE: Every registered channel receives each signal, so this channel
+	// observes both the signal that cancels the context and any second
+	// signal.
+	received := make(chan os.Signal, 2)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() { done <- execute(ctx) }()
+
+	return r.wait(ctx, received, done)
+}
+
+// wait returns the exit status code as described by Run, once the command
+// running with ctx sends the error it returns to done, or once the signals
+// sent to received require the program to exit before then.
+func (r *Runner) wait(ctx context.Context, received <-chan os.Signal, done <-chan error) int {
+	var first os.Signal
+	select {
+	case err := <-done:
+		if ctx.Err() == nil {
+			return ExitCode(err)
+		}
+		// NOTE: The signal that cancelled the context is also sent to
+		// received, but the signal package sends to registered channels in
+		// no particular order, so it may not have been selected yet.
+		first = <-received
+		debug("received first signal: %v\n", first)
+		return signaledExitCode(err, first)
+	case first = <-received:
+		debug("received first signal: %v\n", first)
+	}
+
+	var grace <-chan time.Time
+	if r.GracePeriod > 0 {
+		timer := time.NewTimer(r.GracePeriod)
+		defer timer.Stop()
+		grace = timer.C
+	}
+
+	select {
+	case err := <-done:
+		return signaledExitCode(err, first)
+	case second := <-received:
+		debug("received second signal: %v\n", second)
+		return r.exit(signalExitCode(second))
+	case <-grace:
+		debug("grace period elapsed after signal: %v\n", first)
+		return r.exit(signalExitCode(first))
+	}
+}
+
+// exit invokes the Exit function of r with code, and returns code in case the
+// Exit function returns.
+func (r *Runner) exit(code int) int {
+	if r.Exit != nil {
+		r.Exit(code)
+	} else {
+		os.Exit(code)
+	}
+	return code
+}
@@ -0,0 +1,96 @@
+package golf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{ErrHelp, 0},
+		{&UsageError{Command: &Command{Name: "prog"}, Err: errors.New("missing command")}, 2},
+		{errors.New("failed"), 1},
+		{&ExitError{Code: 7}, 7},
+		{fmt.Errorf("wrapped: %w", &ExitError{Code: 7, Err: errors.New("failed")}), 7},
+		{&ExitError{Code: 0}, 1},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("%v: GOT: %v; WANT: %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	t.Run("context provided", func(t *testing.T) {
+		var got context.Context
+		root := &Command{
+			Name: "prog",
+			RunContext: func(ctx context.Context, args []string) error {
+				got = ctx
+				return &ExitError{Code: 4}
+			},
+		}
+
+		var r Runner
+		if got, want := r.Run(root, nil), 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got == nil {
+			t.Errorf("GOT: %v; WANT: context", got)
+		}
+	})
+
+	t.Run("usage error", func(t *testing.T) {
+		root := &Command{Name: "prog", Output: new(strings.Builder), Run: func(args []string) error { return nil }}
+
+		var r Runner
+		if got, want := r.Run(root, []string{"--unknown"}), 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestRunnerRunMultiCall(t *testing.T) {
+	root, ran := newTestTree(t)
+	root.Output = new(strings.Builder)
+
+	var r Runner
+	if got, want := r.RunMultiCall(root, []string{"foo", "some"}), 0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	ensureStringSlicesMatch(t, *ran, []string{"foo some"})
+}
+
+func TestRunnerWait(t *testing.T) {
+	t.Run("command returns before signal is selected", func(t *testing.T) {
+		// The signal package sends the signal to the channel of the context
+		// and to received in no particular order, so the command may return
+		// the cancellation before the signal is sent to received.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		received := make(chan os.Signal, 2)
+		done := make(chan error, 1)
+		done <- ctx.Err()
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			received <- syscall.SIGTERM
+		}()
+
+		var r Runner
+		if got, want := r.wait(ctx, received, done), 128+int(syscall.SIGTERM); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+//go:build unix
+
+package golf
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunnerSignals(t *testing.T) {
+	signalSelf := func(t *testing.T) {
+		t.Helper()
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("cancels context", func(t *testing.T) {
+		root := &Command{
+			Name: "prog",
+			RunContext: func(ctx context.Context, args []string) error {
+				signalSelf(t)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}
+
+		r := Runner{Signals: []os.Signal{syscall.SIGUSR1}}
+		if got, want := r.Run(root, nil), 128+int(syscall.SIGUSR1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("graceful shutdown", func(t *testing.T) {
+		root := &Command{
+			Name: "prog",
+			RunContext: func(ctx context.Context, args []string) error {
+				signalSelf(t)
+				<-ctx.Done()
+				return nil
+			},
+		}
+
+		r := Runner{Signals: []os.Signal{syscall.SIGUSR1}}
+		if got, want := r.Run(root, nil), 0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("second signal", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		root := &Command{
+			Name: "prog",
+			RunContext: func(ctx context.Context, args []string) error {
+				signalSelf(t)
+				<-ctx.Done()
+				signalSelf(t)
+				<-release // ignore cancellation
+				return nil
+			},
+		}
+
+		var exited int
+		r := Runner{
+			Signals: []os.Signal{syscall.SIGUSR1},
+			Exit:    func(code int) { exited = code },
+		}
+		if got, want := r.Run(root, nil), 128+int(syscall.SIGUSR1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := exited, 128+int(syscall.SIGUSR1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		root := &Command{
+			Name: "prog",
+			RunContext: func(ctx context.Context, args []string) error {
+				signalSelf(t)
+				<-release // ignore cancellation
+				return nil
+			},
+		}
+
+		var exited int
+		r := Runner{
+			Signals:     []os.Signal{syscall.SIGUSR1},
+			GracePeriod: 10 * time.Millisecond,
+			Exit:        func(code int) { exited = code },
+		}
+		if got, want := r.Run(root, nil), 128+int(syscall.SIGUSR1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := exited, 128+int(syscall.SIGUSR1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
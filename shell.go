@@ -0,0 +1,142 @@
+package golf
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// exitCommandName is the name of the built-in command that ends a Shell.
+const exitCommandName = "exit"
+
+// Shell runs a command tree interactively, reading one command line at a time
+// and executing it, like a read-eval-print loop.
+type Shell struct {
+	// Prompt is written to the Output of the root command before each line
+	// is read. When empty, no prompt is written.
+	Prompt string
+}
+
+// Run reads lines from input and executes each of them as the arguments of
+// c, which are split into words like a shell does, honoring single quotes,
+// double quotes, and backslash escapes, and ignoring comments that start with
+// '#'. The options of every command in the tree are reset to their default
+// values before each line is executed. The built-in "exit" command, unless c
+// has a child command with that name, and the end of input, end the loop.
+// Errors from executing a line are written to the Output of c, and do not end
+// the loop. Run returns nil when the loop ends normally, the error from
+// reading input, or the error of ctx when it is done.
+func (s *Shell) Run(ctx context.Context, c *Command, input io.Reader) error {
+	w := c.output()
+	scanner := bufio.NewScanner(input)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if s.Prompt != "" {
+			fmt.Fprint(w, s.Prompt)
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		args, err := splitWords(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(w, "%s: %s\n", c.Path(), err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == exitCommandName && c.command(exitCommandName) == nil {
+			return nil
+		}
+
+		c.reset()
+		err = c.ExecuteContext(ctx, args)
+
+		var ue *UsageError
+		if err != nil && !errors.Is(err, ErrHelp) && !errors.As(err, &ue) {
+			// Usage errors have already been written along with the usage.
+			fmt.Fprintf(w, "%s: %s\n", c.Path(), err)
+		}
+	}
+}
+
+// reset restores the default value of the options of c and all of its
+// descendants, so the tree may execute another command line.
+func (c *Command) reset() {
+	c.Parser.reset()
+	c.Persistent.reset()
+	for _, child := range c.children {
+		child.reset()
+	}
+}
+
+// splitWords splits line into words the way a POSIX shell does, without
+// expanding variables or globs. Single quotes preserve every character they
+// enclose. Double quotes preserve every character they enclose, except that a
+// backslash escapes a following double quote, backslash, dollar sign, or
+// backtick. Outside of quotes, a backslash escapes the following character,
+// and an unquoted '#' at the start of a word begins a comment.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var inWord bool // true when word has started, even if empty, as in ''
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			return words, nil // remainder of line is a comment
+		case r == '\\':
+			i++
+			if i == len(runes) {
+				return nil, errors.New("cannot split line: trailing backslash")
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("cannot split line: unterminated single quote")
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end
+			inWord = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, errors.New("cannot split line: unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
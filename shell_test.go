@@ -0,0 +1,131 @@
+package golf
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestShellRun(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		prompt string
+		done   bool // context is done before the shell starts
+		input  string
+		err    string
+		ran    []string
+		output string   // output, when not empty
+		prefix string   // prefix of the output
+		lines  []string // contained in the output
+	}{
+		{
+			name:  "fresh options each line",
+			input: "user -v --name 'J. Doe' a\\ b\nuser\n",
+			ran:   []string{`user J. Doe true ["a b"]`, `user nobody false []`},
+		},
+		{
+			name:  "errors do not end loop",
+			input: "frob\nuser --bogus\nuser \"unterminated\nuser -n ok\n",
+			ran:   []string{`user ok false []`},
+			lines: []string{
+				`admin: unknown command: "frob"`,
+				`admin user: unknown flag: "bogus"`,
+				`admin: cannot split line: unterminated double quote`,
+			},
+		},
+		{
+			name:   "help",
+			input:  "help user\n",
+			prefix: "Usage: admin user [-n STRING] [arguments]\n",
+		},
+		{
+			name:   "exit",
+			prompt: "> ",
+			input:  "\n# comment\nuser\nexit\nuser\n",
+			ran:    []string{`user nobody false []`},
+			output: "> > > > ",
+		},
+		{
+			name:  "context done",
+			done:  true,
+			input: "user\n",
+			err:   "context canceled",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var ran []string
+			var output strings.Builder
+
+			root := &Command{Name: "admin", Output: &output}
+			verbose := root.Persistent.WithBoolP('v', "verbose", false, "print verbose info")
+
+			user := &Command{Name: "user", Summary: "Show a user."}
+			name := user.Parser.WithStringP('n', "name", "nobody", "name of user")
+			user.Run = func(args []string) error {
+				ran = append(ran, fmt.Sprintf("user %s %t %q", *name, *verbose, args))
+				return nil
+			}
+			root.AddCommand(user)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.done {
+				cancel()
+			}
+
+			s := Shell{Prompt: tc.prompt}
+			ensureError(t, s.Run(ctx, root, strings.NewReader(tc.input)), tc.err)
+			ensureStringSlicesMatch(t, ran, tc.ran)
+
+			got := output.String()
+			if tc.output != "" && got != tc.output {
+				t.Errorf("GOT: %q; WANT: %q", got, tc.output)
+			}
+			if !strings.HasPrefix(got, tc.prefix) {
+				t.Errorf("GOT: %q; WANT PREFIX: %q", got, tc.prefix)
+			}
+			for _, want := range tc.lines {
+				if !strings.Contains(got, want) {
+					t.Errorf("GOT: %q; WANT: %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want []string
+		err  string
+	}{
+		{line: "", want: nil},
+		{line: "  one\ttwo  ", want: []string{"one", "two"}},
+		{line: `a\ b 'c d' "e \"f\" \g"`, want: []string{"a b", "c d", `e "f" \g`}},
+		{line: `'' ""`, want: []string{"", ""}},
+		{line: `pre'mid'"post"`, want: []string{"premidpost"}},
+		{line: "one # two", want: []string{"one"}},
+		{line: "one#two", want: []string{"one#two"}},
+		{line: `one\`, err: "trailing backslash"},
+		{line: `'one`, err: "unterminated single quote"},
+		{line: `"one`, err: "unterminated double quote"},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			got, err := splitWords(tc.line)
+			if tc.err != "" {
+				ensureError(t, err, tc.err)
+				return
+			}
+			ensureError(t, err)
+			if len(got) != len(tc.want) {
+				t.Fatalf("GOT: %q; WANT: %q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("GOT: %q; WANT: %q", got, tc.want)
+				}
+			}
+		})
+	}
+}
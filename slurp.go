@@ -47,7 +47,7 @@ type runeParserStateType uint
 
 const (
 	beginArgument runeParserStateType = iota
-	consumedHyphen
+	consumedPrefix
 	wantLongName
 	wantShortFlagsOnly
 	wantText
@@ -59,8 +59,8 @@ func (state runeParserStateType) String() string {
 	switch state {
 	case beginArgument:
 		return "new argument"
-	case consumedHyphen:
-		return "consumed single hyphen"
+	case consumedPrefix:
+		return "consumed single prefix"
 	case wantLongName:
 		return "want long name"
 	case wantShortFlagsOnly:
@@ -0,0 +1,378 @@
+package golf
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// DefaultUsageTemplate is the text/template used to render usage messages,
+// unless another one is configured by WithUsageTemplate. It is executed with
+// a UsageData value, and may serve as a starting point for other templates.
+const DefaultUsageTemplate = `Usage: {{.Synopsis}}
+{{with .Summary}}
+{{$.Wrap "" .}}{{end -}}
+{{with .Description}}
+{{$.Wrap "" .}}{{end -}}
+{{with .Aliases}}
+Aliases: {{range $i, $alias := .}}{{if $i}}, {{end}}{{$alias}}{{end}}
+{{end -}}
+{{with .Deprecated}}
+{{$.Wrap "" (printf "Deprecated: %s" .)}}{{end -}}
+{{with .Commands}}
+Commands:
+{{range .}}  {{if .Summary}}{{$.PadCommand .Name}}  {{.Summary}}{{else}}{{.Name}}{{end}}
+{{end}}{{end -}}
+{{with .Plugins}}
+Plugins:
+{{range .}}  {{.}}
+{{end}}{{end -}}
+{{range .Groups}}
+{{with .Name}}{{.}}:
+{{end}}{{$.FormatOptions .Options}}{{end -}}
+{{with .Examples}}
+Examples:
+{{range .}}  {{.Command}}
+{{with .Description}}{{$.Wrap "    " .}}{{end}}{{end}}{{end -}}
+`
+
+// defaultUsageTemplate is the parsed form of DefaultUsageTemplate.
+var defaultUsageTemplate = template.Must(template.New("usage").Parse(DefaultUsageTemplate))
+
+// UsageData is the data model provided to usage templates.
+type UsageData struct {
+	Program     string         // name of the program, or path of the command
+	Synopsis    string         // synopsis of the command line
+	Summary     string         // brief description of the command
+	Description string         // description provided by WithDescription
+	Aliases     []string       // aliases of the command
+	Deprecated  string         // deprecation message of the command, if any
+	Commands    []UsageCommand // child commands, including the built-in help command
+	Plugins     []string       // names of plugins that act as child commands
+	Groups      []UsageGroup   // options, in groups
+	Examples    []UsageExample // examples provided by WithExample
+	Files       []UsageFile    // files provided by WithFile
+	Layout      HelpLayout     // layout of options provided by WithHelpLayout
+	Width       int            // number of columns to wrap text to
+}
+
+// UsageCommand describes a child command in a UsageData.
+type UsageCommand struct {
+	Name    string
+	Aliases []string
+	Summary string
+}
+
+// UsageGroup describes a group of options in a UsageData. Options that do not
+// belong to a group declared by WithGroup are in a group named "Options", or
+// "Global options" for the global options of a command, which precedes the
+// other groups. Only the options that do not belong to a group are in a group
+// with an empty name when provided by PrintDefaultsTo.
+type UsageGroup struct {
+	Name    string
+	Options []UsageOption
+}
+
+// UsageOption describes an option in a UsageData.
+type UsageOption struct {
+	Flags       string // flags with their prefixes, such as "-s, --server"
+	Short       string // short flag without its prefix, if any
+	Long        string // long flag without its prefix, if any
+	Metavar     string // placeholder for the argument, empty for booleans
+	Default     string // default value, empty for booleans
+	Env         string // environment variable provided by WithEnv, if any
+	Required    bool   // true when declared by WithRequired
+	Description string // description, with backquotes removed
+
+	value string // default value, not quoted, empty for booleans
+}
+
+// annotations returns the notes that follow the flags of o in a usage
+// message, each preceded by a space.
+func (o UsageOption) annotations() string {
+	var s string
+	if o.Required {
+		s += " (required)" // default value is never used
+	} else if o.Default != "" {
+		s += " (default: " + o.Default + ")"
+	}
+	if o.Env != "" {
+		s += " (env: " + o.Env + ")"
+	}
+	return s
+}
+
+// column returns the indented flags and metavar of o, which are displayed in
+// the left column with HelpLayoutColumns.
+func (o UsageOption) column() string {
+	s := "  " + o.Flags
+	if o.Metavar != "" {
+		s += " " + o.Metavar
+	}
+	return s
+}
+
+// UsageExample describes an example invocation in a UsageData.
+type UsageExample struct {
+	Command     string
+	Description string
+}
+
+// UsageFile describes a file used by the program in a UsageData.
+type UsageFile struct {
+	Path        string
+	Description string
+}
+
+// FormatOptions returns options formatted according to the layout of d, with
+// each option indented by two spaces. With HelpLayoutColumns, descriptions are
+// aligned across the options of every group of d.
+func (d UsageData) FormatOptions(options []UsageOption) string {
+	var b strings.Builder
+
+	column := d.optionsColumn()
+	if column == 0 {
+		wrapper := LineWrapper{Max: d.Width, Prefix: "    "}
+		for _, opt := range options {
+			b.WriteString(opt.column() + opt.annotations() + "\n")
+			if opt.Description != "" {
+				b.WriteString(wrapper.Wrap(opt.Description))
+			}
+		}
+		return b.String()
+	}
+
+	wrapper := LineWrapper{Max: d.width() - column}
+	indent := strings.Repeat(" ", column)
+	for _, opt := range options {
+		left := opt.column()
+		description := strings.TrimSpace(opt.Description + opt.annotations())
+		if description == "" {
+			b.WriteString(left + "\n")
+			continue
+		}
+		lines := strings.SplitAfter(wrapper.Wrap(description), "\n")
+		b.WriteString(left + strings.Repeat(" ", column-utf8.RuneCountInString(left)) + lines[0])
+		for _, line := range lines[1:] {
+			if line != "" {
+				b.WriteString(indent + line)
+			}
+		}
+	}
+	return b.String()
+}
+
+// optionsColumn returns the column where descriptions of options start with
+// HelpLayoutColumns, which is two spaces after the widest flags of the options
+// of every group of d, or 0 when options ought to be stacked, either because
+// of the layout, or because the flags would take more than half the width.
+func (d UsageData) optionsColumn() int {
+	if d.Layout != HelpLayoutColumns {
+		return 0
+	}
+	var column int
+	for _, group := range d.Groups {
+		for _, opt := range group.Options {
+			column = max(column, utf8.RuneCountInString(opt.column())+2)
+		}
+	}
+	if column > d.width()/2 {
+		return 0
+	}
+	return column
+}
+
+// PadCommand returns name padded with spaces to the length of the longest
+// name of the commands of d.
+func (d UsageData) PadCommand(name string) string {
+	var width int
+	for _, cmd := range d.Commands {
+		width = max(width, len(cmd.Name))
+	}
+	return fmt.Sprintf("%-*s", width, name)
+}
+
+// width returns the number of columns to wrap text to, which is 80 when the
+// Width of d is 0, as it is for LineWrapper.
+func (d UsageData) width() int {
+	if d.Width == 0 {
+		return defaultWidth
+	}
+	return d.Width
+}
+
+// Wrap returns text wrapped to the width of d, with each line prefixed by
+// prefix, and ending with a newline.
+func (d UsageData) Wrap(prefix, text string) string {
+	return LineWrapper{Max: d.Width, Prefix: prefix}.Wrap(text)
+}
+
+// PrintUsageTo prints to w the usage of the Parser for program, rendered by its
+// usage template, including the synopsis, description, options, and examples.
+func (p *Parser) PrintUsageTo(w io.Writer, program string) {
+	renderUsage(w, p.usageTemplate, p.usageData(program, true))
+}
+
+// UsageData returns the data model provided to usage templates that describes
+// the Parser for program. Because it may be used to generate documentation,
+// hidden options are only included when revealed by WithShowHidden.
+func (p *Parser) UsageData(program string) UsageData {
+	return p.usageData(program, false)
+}
+
+// usageData returns the data model that describes the Parser for program,
+// including the options displayed as described by displayed.
+func (p *Parser) usageData(program string, interactive bool) UsageData {
+	return UsageData{
+		Program:     program,
+		Synopsis:    p.synopsis(program, interactive),
+		Description: p.description,
+		Groups:      p.usageGroups("Options", p.options, interactive),
+		Examples:    p.examples,
+		Files:       p.files,
+		Layout:      p.helpLayout,
+		Width:       p.width(),
+	}
+}
+
+// usageGroups returns the groups of options that ought to be displayed.
+// Options without a group are in the first group, with the specified name,
+// followed by the options of each group, in the order the groups were
+// declared. Empty groups are omitted.
+func (p *Parser) usageGroups(name string, options []option, interactive bool) []UsageGroup {
+	var ungrouped []UsageOption
+	grouped := make(map[string][]UsageOption)
+	names := append([]string(nil), p.groups...)
+
+	for _, opt := range options {
+		if !p.displayed(opt, interactive) {
+			continue
+		}
+		group := opt.attributes().group
+		if group == "" {
+			ungrouped = append(ungrouped, p.usageOption(opt))
+			continue
+		}
+		if _, ok := grouped[group]; !ok && !slices.Contains(names, group) {
+			names = append(names, group) // group declared by another parser
+		}
+		grouped[group] = append(grouped[group], p.usageOption(opt))
+	}
+
+	var groups []UsageGroup
+	if len(ungrouped) > 0 {
+		groups = append(groups, UsageGroup{Name: name, Options: ungrouped})
+	}
+	for _, name := range names {
+		if options := grouped[name]; len(options) > 0 {
+			groups = append(groups, UsageGroup{Name: name, Options: options})
+		}
+	}
+	return groups
+}
+
+// usageOption returns the description of opt provided to usage templates.
+func (p *Parser) usageOption(opt option) UsageOption {
+	prefix := string(p.displayPrefix())
+	attrs := opt.attributes()
+	name, description := metavar(opt)
+
+	uo := UsageOption{
+		Short:       opt.Short(),
+		Long:        opt.Long(),
+		Metavar:     name,
+		Env:         attrs.env,
+		Required:    attrs.required,
+		Description: description,
+	}
+
+	var flags []string
+	if uo.Short != "" {
+		flags = append(flags, prefix+uo.Short)
+	}
+	if uo.Long != "" {
+		flags = append(flags, prefix+prefix+uo.Long)
+	}
+	uo.Flags = strings.Join(flags, ", ")
+
+	switch value := opt.Default().(type) {
+	case bool:
+		// do not want to add a default blob when boolean
+	case string:
+		uo.Default = fmt.Sprintf("%q", value)
+		uo.value = value
+	default:
+		uo.Default = fmt.Sprintf("%v", value)
+		uo.value = uo.Default
+	}
+	return uo
+}
+
+// PrintUsageTo prints to w the usage of c, rendered by the usage template of
+// its Parser, or of the Parser of its nearest ancestor that has one, including
+// its summary, its child commands, and the default settings of its options.
+func (c *Command) PrintUsageTo(w io.Writer) {
+	tmpl := c.Parser.usageTemplate
+	for cmd := c.parent; tmpl == nil && cmd != nil; cmd = cmd.parent {
+		tmpl = cmd.Parser.usageTemplate
+	}
+	renderUsage(w, tmpl, c.usageData(true))
+}
+
+// UsageData returns the data model provided to usage templates that describes
+// c. Because it may be used to generate documentation, hidden options are only
+// included when revealed by WithShowHidden.
+func (c *Command) UsageData() UsageData {
+	return c.usageData(false)
+}
+
+// usageData returns the data model that describes c, including the options
+// displayed as described by Parser.displayed.
+func (c *Command) usageData(interactive bool) UsageData {
+	options := make([]option, 0, len(c.Parser.options)+len(c.Persistent.options))
+	options = append(options, c.Parser.options...)
+	options = append(options, c.Persistent.options...)
+
+	data := UsageData{
+		Program:     c.Path(),
+		Synopsis:    c.synopsis(interactive),
+		Summary:     c.Summary,
+		Description: c.Parser.description,
+		Aliases:     c.Aliases,
+		Plugins:     c.pluginNames(),
+		Groups:      append(c.Parser.usageGroups("Options", options, interactive), c.Parser.usageGroups("Global options", c.globals(), interactive)...),
+		Examples:    c.Parser.examples,
+		Files:       c.Parser.files,
+		Layout:      c.Parser.helpLayout,
+		Width:       c.Parser.width(),
+	}
+	if c.Deprecated != "" {
+		data.Deprecated = c.deprecation()
+	}
+
+	for _, child := range c.children {
+		if child.listed() {
+			data.Commands = append(data.Commands, UsageCommand{Name: child.Name, Aliases: child.Aliases, Summary: child.Summary})
+		}
+	}
+	if len(c.children) > 0 && c.command(helpCommandName) == nil {
+		data.Commands = append(data.Commands, UsageCommand{Name: helpCommandName, Summary: "Show help for a command."})
+	}
+	return data
+}
+
+// renderUsage executes tmpl, or the default usage template when tmpl is nil,
+// with data, writing the result to w. Because usage is displayed when
+// something has already gone wrong, an error from executing the template is
+// written to w rather than returned.
+func renderUsage(w io.Writer, tmpl *template.Template, data UsageData) {
+	if tmpl == nil {
+		tmpl = defaultUsageTemplate
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		fmt.Fprintf(w, "cannot render usage: %s\n", err)
+	}
+}
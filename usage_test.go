@@ -0,0 +1,208 @@
+package golf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParserPrintUsageTo(t *testing.T) {
+	var p Parser
+	p.WithWidth(80)
+	p.WithDescription("Serve files over the network.")
+	p.WithBoolP('v', "verbose", false, "print verbose info")
+	p.WithStringP('s', "server", "localhost", "listen on `HOST`")
+	p.WithInt("port", 80, "listen on port")
+	p.WithString("log-level", "info", "minimum level of logged events")
+	p.WithGroup("Networking", "server", "port")
+	p.WithEnv("server", "SERVER")
+	p.WithRequired("port")
+	p.WithPositionals("DIR")
+	p.WithExample("serve -s example.com /srv", "Serve /srv on example.com.")
+	ensureError(t, p.Err())
+
+	var output strings.Builder
+	p.PrintUsageTo(&output, "serve")
+
+	want := `Usage: serve [-v] [-s HOST] --port INT [--log-level STRING] DIR
+
+Serve files over the network.
+
+Options:
+  -v, --verbose
+    print verbose info
+  --log-level STRING (default: "info")
+    minimum level of logged events
+
+Networking:
+  -s, --server HOST (default: "localhost") (env: SERVER)
+    listen on HOST
+  --port INT (required)
+    listen on port
+
+Examples:
+  serve -s example.com /srv
+    Serve /srv on example.com.
+`
+	if got := output.String(); got != want {
+		t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+	}
+}
+
+func TestParserWithUsageTemplate(t *testing.T) {
+	t.Run("custom", func(t *testing.T) {
+		var p Parser
+		p.WithBoolP('v', "verbose", false, "print verbose info")
+		p.WithInt("n", 3, "count")
+		p.WithUsageTemplate(`{{.Program}}:{{range .Groups}}{{range .Options}} {{.Flags}}={{.Default}}{{end}}{{end}}`)
+		ensureError(t, p.Err())
+
+		var output strings.Builder
+		p.PrintUsageTo(&output, "prog")
+		if got, want := output.String(), "prog: -v, --verbose= -n=3"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("inherited by commands", func(t *testing.T) {
+		root, _ := newTestTree(t)
+		root.Parser.WithUsageTemplate(`{{.Program}}{{range .Commands}} {{.Name}}{{end}}` + "\n")
+		ensureError(t, root.Parser.Err())
+
+		var output strings.Builder
+		root.PrintUsageTo(&output)
+		root.Commands()[1].PrintUsageTo(&output)
+		if got, want := output.String(), "prog foo bar help\nprog bar baz help\n"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("execution error", func(t *testing.T) {
+		var p Parser
+		p.WithUsageTemplate(`{{.Bogus}}`)
+
+		var output strings.Builder
+		p.PrintUsageTo(&output, "prog")
+		if got, want := output.String(), "cannot render usage: "; !strings.HasPrefix(got, want) {
+			t.Errorf("GOT: %q; WANT PREFIX: %q", got, want)
+		}
+	})
+
+	ensureParserError(t, "cannot parse usage template: ", func(t *testing.T, p *Parser) {
+		p.WithUsageTemplate(`{{.Program`)
+	})
+}
+
+func TestCommandUsageData(t *testing.T) {
+	root, _ := newTestTree(t)
+	root.AddCommand(&Command{Name: "secret", Hidden: true})
+	bar := root.Commands()[1]
+	bar.Aliases = []string{"b"}
+	bar.Persistent.WithString("config", "", "read `FILE`")
+	root.Persistent.WithBoolP('q', "quiet", false, "print nothing")
+
+	data := bar.UsageData()
+
+	if got, want := data.Program, "prog bar"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+	ensureStringSlicesMatch(t, data.Aliases, []string{"b"})
+
+	var commands []string
+	for _, cmd := range data.Commands {
+		commands = append(commands, cmd.Name)
+	}
+	ensureStringSlicesMatch(t, commands, []string{"baz", "help"})
+
+	var groups []string
+	for _, group := range data.Groups {
+		for _, opt := range group.Options {
+			groups = append(groups, group.Name+" "+opt.Flags+" "+opt.Metavar)
+		}
+	}
+	ensureStringSlicesMatch(t, groups, []string{"Options --config FILE", "Global options -q, --quiet "})
+}
+
+func TestParserWithEnv(t *testing.T) {
+	ensureParserError(t, `cannot configure unknown flag: "server"`, func(t *testing.T, p *Parser) {
+		p.WithEnv("server", "SERVER")
+	})
+
+	ensureParserError(t, `cannot use environment variable without name for flag: "server"`, func(t *testing.T, p *Parser) {
+		p.WithString("server", "", "listen on `HOST`")
+		p.WithEnv("server", "")
+	})
+}
+
+func TestParserWithHelpLayout(t *testing.T) {
+	t.Run("columns", func(t *testing.T) {
+		var p Parser
+		p.WithWidth(60)
+		p.WithHelpLayout(HelpLayoutColumns)
+		p.WithBoolP('v', "verbose", false, "print verbose info")
+		p.WithStringP('s', "server", "localhost", "listen on `HOST`, which may be a host name or an address")
+		p.WithInt("port", 80, "listen on port")
+		p.WithBool("q", false, "")
+		p.WithGroup("Networking", "server", "port")
+		p.WithRequired("port")
+		ensureError(t, p.Err())
+
+		var output strings.Builder
+		p.PrintUsageTo(&output, "serve")
+
+		want := `Usage: serve [-qv] [-s HOST] --port INT
+
+Options:
+  -v, --verbose      print verbose info
+  -q
+
+Networking:
+  -s, --server HOST  listen on HOST, which may be a host
+                     name or an address (default:
+                     "localhost")
+  --port INT         listen on port (required)
+`
+		if got := output.String(); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("print defaults", func(t *testing.T) {
+		var p Parser
+		p.WithWidth(80)
+		p.WithHelpLayout(HelpLayoutColumns)
+		p.WithBoolP('v', "verbose", false, "print verbose info")
+		p.WithDuration("timeout", 0, "give up after timeout")
+		ensureError(t, p.Err())
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+
+		want := `  -v, --verbose       print verbose info
+  --timeout DURATION  give up after timeout (default: 0s)
+`
+		if got := output.String(); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+
+	t.Run("falls back to stacked when flags are too wide", func(t *testing.T) {
+		var p Parser
+		p.WithWidth(40)
+		p.WithHelpLayout(HelpLayoutColumns)
+		p.WithBoolP('v', "verbose", false, "print verbose info")
+		p.WithString("configuration-file", "", "read settings from `PATH`")
+		ensureError(t, p.Err())
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+
+		want := `  -v, --verbose
+    print verbose info
+  --configuration-file PATH (default: "")
+    read settings from PATH
+`
+		if got := output.String(); got != want {
+			t.Errorf("GOT:\n%s\nWANT:\n%s", got, want)
+		}
+	})
+}
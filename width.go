@@ -0,0 +1,98 @@
+package golf
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Widths used when displaying usage messages. Widths provided by a
+// WidthProvider are clamped between minWidth and maxWidth, so that usage
+// messages remain legible in very narrow and very wide terminals.
+const (
+	defaultWidth = 80
+	minWidth     = 40
+	maxWidth     = 120
+)
+
+// WidthProvider provides the number of columns available for displaying usage
+// messages, such as the width of a terminal.
+type WidthProvider interface {
+	// Width returns the number of columns, or 0 when unknown.
+	Width() int
+}
+
+// WidthFunc is a function that acts as a WidthProvider.
+type WidthFunc func() int
+
+func (f WidthFunc) Width() int { return f() }
+
+// ColumnsWidth returns a WidthProvider that provides the number of columns
+// from the COLUMNS environment variable, which many shells set to the width
+// of the terminal.
+func ColumnsWidth() WidthProvider {
+	return WidthFunc(func() int {
+		columns, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS")))
+		if err != nil || columns < 0 {
+			return 0
+		}
+		return columns
+	})
+}
+
+// FirstWidth returns a WidthProvider that provides the width from the first of
+// providers that knows its width.
+func FirstWidth(providers ...WidthProvider) WidthProvider {
+	return WidthFunc(func() int {
+		for _, provider := range providers {
+			if columns := provider.Width(); columns > 0 {
+				return columns
+			}
+		}
+		return 0
+	})
+}
+
+// TerminalWidth returns a WidthProvider that provides the width of the
+// terminal f refers to, or 0 when f does not refer to a terminal, or when the
+// width of a terminal cannot be determined on this operating system.
+func TerminalWidth(f *os.File) WidthProvider {
+	return WidthFunc(func() int {
+		if f == nil {
+			return 0
+		}
+		return terminalWidth(f.Fd())
+	})
+}
+
+// defaultWidthProvider provides the width of usage messages for a Parser that
+// has not been configured with a width or WidthProvider.
+var defaultWidthProvider = FirstWidth(ColumnsWidth(), TerminalWidth(os.Stderr), TerminalWidth(os.Stdout))
+
+// width returns the number of columns available for displaying the usage
+// message of the Parser.
+func (p *Parser) width() int {
+	if p.columns > 0 {
+		return p.columns
+	}
+	provider := p.widthProvider
+	if provider == nil {
+		provider = defaultWidthProvider
+	}
+	switch columns := provider.Width(); {
+	case columns <= 0:
+		return defaultWidth
+	case columns < minWidth:
+		return minWidth
+	case columns > maxWidth:
+		return maxWidth
+	default:
+		return columns
+	}
+}
+
+// wrapper returns a LineWrapper that wraps lines to the width of the usage
+// message of the Parser, prefixing each line with prefix.
+func (p *Parser) wrapper(prefix string) LineWrapper {
+	return LineWrapper{Max: p.width(), Prefix: prefix}
+}
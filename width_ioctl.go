@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd
+
+package golf
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth returns the number of columns of the terminal referred to by
+// the file descriptor fd, or 0 when fd does not refer to a terminal.
+func terminalWidth(fd uintptr) int {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd)
+
+package golf
+
+// terminalWidth returns 0, because the width of a terminal cannot be
+// determined on this operating system.
+func terminalWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,106 @@
+package golf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParserWidth(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		columns  int
+		provider WidthProvider
+		want     int
+	}{
+		{name: "explicit", columns: 50, provider: WidthFunc(func() int { return 100 }), want: 50},
+		{name: "explicit below minimum", columns: 20, want: 20},
+		{name: "provided", provider: WidthFunc(func() int { return 100 }), want: 100},
+		{name: "provided below minimum", provider: WidthFunc(func() int { return 10 }), want: minWidth},
+		{name: "provided above maximum", provider: WidthFunc(func() int { return 200 }), want: maxWidth},
+		{name: "provided unknown", provider: WidthFunc(func() int { return 0 }), want: defaultWidth},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var p Parser
+			p.WithWidth(tc.columns).WithWidthProvider(tc.provider)
+			ensureError(t, p.Err())
+			if got, want := p.width(), tc.want; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	}
+
+	t.Run("wraps descriptions", func(t *testing.T) {
+		var p Parser
+		p.WithWidth(40)
+		p.WithBool("v", false, "print verbose information about every step taken")
+
+		var output strings.Builder
+		p.PrintDefaultsTo(&output)
+
+		want := "  -v\n    print verbose information about every\n    step taken\n"
+		if got := output.String(); got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	ensureParserError(t, "cannot use negative width: -1", func(t *testing.T, p *Parser) {
+		p.WithWidth(-1)
+	})
+}
+
+func TestColumnsWidth(t *testing.T) {
+	for _, tc := range []struct {
+		columns string
+		want    int
+	}{
+		{columns: "132", want: 132},
+		{columns: " 100\n", want: 100},
+		{columns: "", want: 0},
+		{columns: "wide", want: 0},
+		{columns: "-5", want: 0},
+	} {
+		t.Run(tc.columns, func(t *testing.T) {
+			t.Setenv("COLUMNS", tc.columns)
+			if got, want := ColumnsWidth().Width(), tc.want; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultWidthProvider(t *testing.T) {
+	t.Setenv("COLUMNS", "100")
+	if got, want := environmentWidthProvider.Width(), 100; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestFirstWidth(t *testing.T) {
+	unknown := WidthFunc(func() int { return 0 })
+	first := WidthFunc(func() int { return 60 })
+	second := WidthFunc(func() int { return 90 })
+
+	if got, want := FirstWidth(unknown, first, second).Width(), 60; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := FirstWidth(unknown).Width(), 0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "not-a-terminal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if got, want := TerminalWidth(f).Width(), 0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := TerminalWidth(nil).Width(), 0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}